@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bookmarker/internal/dbutil"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"fmt"
+	"log"
+)
+
+// assignBookmarksCommand gives every bookmark without an owner to the user, e.g. after an import without a username
+func assignBookmarksCommand(username string) {
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	user, err := repositories.NewUserRepository(db).GetUserByUsername(username)
+	if err != nil {
+		log.Fatalf("Failed to find user %q: %v", username, err)
+	}
+	bookmarkService := services.NewBookmarkService(repositories.NewBookmarkRepository(db))
+	assigned, err := bookmarkService.AssignUnownedBookmarks(int(user.ID))
+	if err != nil {
+		log.Fatalf("Failed to assign bookmarks: %v", err)
+	}
+	fmt.Printf("Assigned %d bookmarks to user: ID=%d, Username=%s\n", assigned, user.ID, user.Username)
+}
@@ -8,7 +8,15 @@ import (
 	"log"
 )
 
+// createUserCommand creates a user, prompting for the password when none is given
 func createUserCommand(username, password string) {
+	if password == "" {
+		var err error
+		password, err = readPassword()
+		if err != nil {
+			log.Fatalf("Failed to read password: %v", err)
+		}
+	}
 	db, err := dbutil.OpenPostgresDB()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
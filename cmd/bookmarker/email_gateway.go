@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"log"
+	"net"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// startEmailGateway starts the SMTP listener of the email gateway on EMAIL_SMTP_ADDR (e.g. "127.0.0.1:2525").
+// It returns nil when EMAIL_SMTP_ADDR is not set. The listener has no authentication and trusts the sender
+// address, so it only binds to a non-loopback address when EMAIL_SMTP_TRUSTED_RELAY is true, i.e. when a
+// mail server that checks senders (SPF, DKIM) is the only thing that can reach it.
+func startEmailGateway(db *pgxpool.Pool) *services.SMTPServer {
+	addr := os.Getenv("EMAIL_SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	if !isLoopbackAddr(addr) && os.Getenv("EMAIL_SMTP_TRUSTED_RELAY") != "true" {
+		log.Printf("Email gateway not started: EMAIL_SMTP_ADDR %s is not a loopback address and EMAIL_SMTP_TRUSTED_RELAY is not true", addr)
+		return nil
+	}
+	domain := os.Getenv("EMAIL_SMTP_DOMAIN")
+	if domain == "" {
+		domain = "localhost"
+	}
+	bookmarkService := services.NewBookmarkServiceWithWebhooks(db)
+	gateway := services.NewEmailGatewayService(bookmarkService, repositories.NewEmailRepository(db))
+	server := &services.SMTPServer{
+		Addr:            addr,
+		Domain:          domain,
+		MaxMessageBytes: services.EmailMaxMessageBytes,
+		Handler:         gateway.HandleSMTP,
+	}
+	go func() {
+		log.Printf("Email gateway listening for SMTP on %s", addr)
+		if err := server.ListenAndServe(); err != nil {
+			log.Printf("Email gateway stopped: %v", err)
+		}
+	}()
+	return server
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local connections
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -10,18 +10,23 @@ import (
 	"path/filepath"
 )
 
-// importPinboard runs the import-pinboard command
-func importPinboard(filename string) {
+// importPinboard runs the import-pinboard command. If username is set, the imported bookmarks belong to that user.
+func importPinboard(filename string, username string) {
 	db, err := dbutil.OpenPostgresDB()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
-	bookmarkRepo := repositories.NewBookmarkRepository(db)
-	tagRepo := repositories.NewTagRepository(db)
-	bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+	bookmarkService := services.NewBookmarkServiceWithWebhooks(db)
 	importService := services.NewPinboardImportService(bookmarkService)
+	if username != "" {
+		user, err := repositories.NewUserRepository(db).GetUserByUsername(username)
+		if err != nil {
+			log.Fatalf("Failed to find user %q: %v", username, err)
+		}
+		importService.UserID = &user.ID
+	}
 
 	filePath := filepath.Join("../../data/import", filename)
 	f, err := os.Open(filePath)
@@ -35,4 +40,7 @@ func importPinboard(filename string) {
 		log.Fatalf("Import failed: %v", err)
 	}
 	fmt.Println("Pinboard import completed successfully.")
+	if username == "" {
+		fmt.Println("The imported bookmarks have no owner; run assign-bookmarks <username> to make them visible.")
+	}
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -27,21 +28,64 @@ func main() {
 
 	if len(os.Args) > 2 && os.Args[1] == "import-pinboard" {
 		filename := os.Args[2]
-		importPinboard(filename)
+		username := ""
+		if len(os.Args) > 3 {
+			username = os.Args[3]
+		}
+		importPinboard(filename, username)
 		return
 	}
-	if len(os.Args) > 3 && os.Args[1] == "create-user" {
+	if len(os.Args) > 2 && os.Args[1] == "create-user" {
 		username := os.Args[2]
-		password := os.Args[3]
+		password := ""
+		if len(os.Args) > 3 {
+			password = os.Args[3]
+		}
 		createUserCommand(username, password)
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "list-users" {
+		listUsersCommand()
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "reset-password" {
+		resetPasswordCommand(os.Args[2])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "disable-user" {
+		setUserDisabledCommand(os.Args[2], true)
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "enable-user" {
+		setUserDisabledCommand(os.Args[2], false)
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "make-admin" {
+		makeAdminCommand(os.Args[2])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "reset-2fa" {
+		resetTwoFactorCommand(os.Args[2])
+		return
+	}
 	if len(os.Args) > 1 && os.Args[1] == "start-server" {
 		db, err := dbutil.OpenPostgresDB()
 		if err != nil {
 			log.Fatalf("Failed to connect to database: %v", err)
 		}
 		r := setupRouter(db)
+		cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+		go runMaintenance(cleanupCtx, db)
+		// TELEGRAM_POLL=true receives bot updates by long polling instead of the webhook
+		if os.Getenv("TELEGRAM_POLL") == "true" {
+			go func() {
+				if err := runTelegramPoll(cleanupCtx, db); err != nil {
+					log.Printf("Telegram polling stopped: %v", err)
+				}
+			}()
+		}
+		go services.NewWebhookDispatcher(repositories.NewWebhookRepository(db)).Run(cleanupCtx)
+		smtpServer := startEmailGateway(db)
 
 		// Graceful shutdown setup
 		quit := make(chan os.Signal, 1)
@@ -57,6 +101,10 @@ func main() {
 		}()
 		<-quit
 		log.Println("Shutting down server...")
+		stopCleanup()
+		if smtpServer != nil {
+			smtpServer.Close()
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := server.Shutdown(ctx); err != nil {
@@ -68,6 +116,30 @@ func main() {
 		log.Println("Server exiting")
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "telegram-poll" {
+		telegramPollCommand()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "telegram-setup" {
+		action := ""
+		if len(os.Args) > 2 {
+			action = os.Args[2]
+		}
+		telegramSetupCommand(action)
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "assign-bookmarks" {
+		assignBookmarksCommand(os.Args[2])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge-trash" {
+		days := ""
+		if len(os.Args) > 2 {
+			days = os.Args[2]
+		}
+		purgeTrashCommand(days)
+		return
+	}
 	if len(os.Args) > 1 && os.Args[1] == "backup-db" {
 		err := services.BackupPostgresDB()
 		if err != nil {
@@ -79,7 +151,7 @@ func main() {
 	if len(os.Args) > 1 {
 		log.Fatalf("Unrecognized command: %s", os.Args[1])
 	}
-	log.Fatalf("No command provided. Use 'start-server', 'import-pinboard <filename>', 'create-user <username> <password>', or 'backup-db'")
+	log.Fatalf("No command provided. Use 'start-server', 'import-pinboard <filename> [username]', 'create-user <username> [password]', 'list-users', 'reset-password <username>', 'disable-user <username>', 'enable-user <username>', 'make-admin <username>', 'reset-2fa <username>', 'telegram-poll', 'telegram-setup [set|info|delete]', 'assign-bookmarks <username>', 'purge-trash [days]', or 'backup-db'")
 }
 
 
@@ -91,7 +163,11 @@ func setupRouter(db *pgxpool.Pool) *gin.Engine {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
-	
+	// Client IPs feed the login throttle, sessions and the audit log, so X-Forwarded-For is only
+	// believed from the proxies in TRUSTED_PROXIES (comma-separated IPs or CIDRs); by default from none
+	if err := r.SetTrustedProxies(trustedProxiesFromEnv()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Apply the Gin CORS middleware
 	r.Use(middleware.CorsMiddleware())
@@ -105,19 +181,37 @@ func setupRouter(db *pgxpool.Pool) *gin.Engine {
 	userRepo := repositories.NewUserRepository(db)
 	tokenRepo := repositories.NewTokenRepository(db)
 	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	loginThrottleRepo := repositories.NewLoginThrottleRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	oidcRepo := repositories.NewOIDCRepository(db)
 
 	// Initialize services
 	userService := services.NewUserService(userRepo)
 	tokenService := services.NewTokenService(tokenRepo)
 	refreshTokenService := services.NewRefreshTokenService(refreshTokenRepo)
-	authService := services.NewAuthService(userService, tokenService, refreshTokenService)
+	sessionService := services.NewSessionService(sessionRepo)
+	loginThrottleService := services.NewLoginThrottleService(loginThrottleRepo)
+	auditService := services.NewAuditService(auditRepo)
+	authService := services.NewAuthService(userService, tokenService, refreshTokenService, sessionService, loginThrottleService, auditService)
+	oidcService := services.NewOIDCService(services.OIDCConfigFromEnv(), oidcRepo, userService)
 
 	// Initialize controllers
 	bookmarksController := controllers.NewBookmarksController(db)
 	searchController := controllers.NewSearchController(db)
 	tagsController := controllers.NewTagsController(db)
+	collectionsController := controllers.NewCollectionsController(db)
+	exportController := controllers.NewExportController(db)
+	sharesController := controllers.NewSharesController(db)
 	userController := controllers.NewUserController(authService)
+	adminController := controllers.NewAdminController(userService)
+	oidcController := controllers.NewOIDCController(oidcService, authService)
 	telegramController := controllers.NewTelegramController(db)
+	emailController := controllers.NewEmailController(db)
+	hooksController := controllers.NewHooksController(db)
+	chatCommandsController := controllers.NewChatCommandsController(db)
+	linkCodesController := controllers.NewLinkCodesController(db)
+	webhooksController := controllers.NewWebhooksController(db)
 	urlController := controllers.NewUrlController()
 	utilityController := controllers.NewUtilityController()
 
@@ -126,24 +220,110 @@ func setupRouter(db *pgxpool.Pool) *gin.Engine {
 	r.POST("/login", userController.Login)
 	r.POST("/refresh", userController.Refresh)
 	r.POST("/logout", userController.Logout)
+	r.POST("/login/2fa", userController.TwoFactorLogin)
+	r.GET("/auth/oidc/login", oidcController.Login)
+	r.GET("/auth/oidc/callback", oidcController.Callback)
 	// Telegram webhook route
 	r.POST("/telegram/listen", telegramController.TelegramWebhookHandler)
+	// Raw RFC 5322 messages from mail services that forward inbound mail over HTTP
+	r.POST("/email/inbound", emailController.InboundEmail)
+	// Signed inbound webhooks and Slack and Discord slash commands
+	r.POST("/hooks/:id", hooksController.ReceiveHook)
+	r.POST("/slack/commands", chatCommandsController.SlackCommand)
+	r.POST("/discord/interactions", chatCommandsController.DiscordInteraction)
 
 	r.POST("/utility/backup-db", utilityController.BackupDBHandler)
 
+	// Public read-only pages for share links
+	r.GET("/shared/:token", sharesController.SharedPage)
+	r.GET("/shared/:token/feed.json", sharesController.SharedFeed)
+
 	// Protected routes
 	r.Use(middleware.AuthMiddleware(authService))
 	r.GET("/bookmarks", bookmarksController.GetBookmarks)
+	r.GET("/bookmarks/shared", bookmarksController.GetSharedBookmarks)
+	r.GET("/bookmarks/next-unread", bookmarksController.GetNextUnread)
 	r.POST("/bookmarks", bookmarksController.CreateBookmark)
+	r.POST("/bookmarks/bulk", bookmarksController.BulkBookmarks)
 	r.GET("/bookmarks/:id", bookmarksController.GetBookmark)
 	r.PATCH("/bookmarks/:id", bookmarksController.UpdateBookmark)
 	r.DELETE("/bookmarks/:id", bookmarksController.DeleteBookmark)
+	r.PUT("/bookmarks/:id/read-state", bookmarksController.SetReadState)
+	r.POST("/bookmarks/:id/highlights", bookmarksController.CreateHighlight)
+	r.DELETE("/bookmarks/:id/highlights/:highlightId", bookmarksController.DeleteHighlight)
+	r.POST("/bookmarks/:id/restore", bookmarksController.RestoreBookmark)
+	r.GET("/bookmarks/:id/history", bookmarksController.GetBookmarkHistory)
+	r.POST("/bookmarks/:id/history/:revisionId/revert", bookmarksController.RevertBookmark)
+	r.GET("/trash", bookmarksController.GetTrash)
 	r.GET("/search", searchController.SearchBookmarks)
 	r.GET("/bookmarks/tag", searchController.GetBookmarksByTag)
 	r.GET("/tags", tagsController.ListTags)
+	r.GET("/tags/aliases", tagsController.ListTagAliases)
+	// Tags are shared by all users, so only admins may change aliases
+	r.POST("/tags/aliases", middleware.AdminMiddleware(authService), tagsController.CreateTagAlias)
+	r.DELETE("/tags/aliases/:alias", middleware.AdminMiddleware(authService), tagsController.DeleteTagAlias)
+	r.PATCH("/tags/:id", middleware.AdminMiddleware(authService), tagsController.RenameTag)
+	r.GET("/collections", collectionsController.ListCollections)
+	r.POST("/collections", collectionsController.CreateCollection)
+	r.GET("/collections/:id", collectionsController.GetCollection)
+	r.PATCH("/collections/:id", collectionsController.UpdateCollection)
+	r.DELETE("/collections/:id", collectionsController.DeleteCollection)
+	r.POST("/collections/:id/bookmarks", collectionsController.AddBookmark)
+	r.DELETE("/collections/:id/bookmarks/:bookmarkId", collectionsController.RemoveBookmark)
+	r.PUT("/collections/:id/order", collectionsController.ReorderBookmarks)
+	r.GET("/export", exportController.ExportBookmarks)
+	r.GET("/shares", sharesController.ListShares)
+	r.POST("/shares", sharesController.CreateShare)
+	r.DELETE("/shares/:id", sharesController.RevokeShare)
 	r.GET("/me", userController.Me)
+	r.GET("/auth/oidc/link", oidcController.Link)
+	r.POST("/me/password", userController.ChangePassword)
+	r.GET("/sessions", userController.ListSessions)
+	r.DELETE("/sessions", userController.RevokeAllSessions)
+	r.DELETE("/sessions/:id", userController.RevokeSession)
+	r.POST("/2fa/setup", userController.SetupTwoFactor)
+	r.POST("/2fa/enable", userController.EnableTwoFactor)
+	r.POST("/2fa/disable", userController.DisableTwoFactor)
+	r.POST("/2fa/recovery-codes", userController.RegenerateRecoveryCodes)
+	r.POST("/telegram/link-code", telegramController.CreateLinkCode)
+	r.POST("/link-codes", linkCodesController.CreateLinkCode)
+	r.GET("/telegram/chats", telegramController.ListChats)
+	r.DELETE("/telegram/chats/:chatId", telegramController.UnlinkChat)
+	r.GET("/email/addresses", emailController.ListAddresses)
+	r.POST("/email/addresses", emailController.AddAddress)
+	r.POST("/email/addresses/confirm", emailController.ConfirmAddress)
+	r.DELETE("/email/addresses/:id", emailController.DeleteAddress)
+	r.GET("/hooks", hooksController.ListHooks)
+	r.POST("/hooks", hooksController.CreateHook)
+	r.DELETE("/hooks/:id", hooksController.DeleteHook)
+	r.GET("/chat-accounts", chatCommandsController.ListChatAccounts)
+	r.DELETE("/chat-accounts/:provider/:accountId", chatCommandsController.UnlinkChatAccount)
+	r.GET("/webhooks", webhooksController.ListWebhooks)
+	r.POST("/webhooks", webhooksController.CreateWebhook)
+	r.PATCH("/webhooks/:id", webhooksController.UpdateWebhook)
+	r.DELETE("/webhooks/:id", webhooksController.DeleteWebhook)
+	r.GET("/webhooks/:id/deliveries", webhooksController.ListDeliveries)
+	r.POST("/webhooks/:id/deliveries/:deliveryId/retry", webhooksController.RetryDelivery)
 	r.GET("/url/preview", urlController.UrlPreviewHandler)
+
+	// Admin routes
+	admin := r.Group("/admin", middleware.AdminMiddleware(authService))
+	admin.GET("/users", adminController.ListUsers)
+	admin.POST("/users/:id/disable", adminController.DisableUser)
+	admin.POST("/users/:id/enable", adminController.EnableUser)
+	admin.DELETE("/users/:id", adminController.DeleteUser)
 	
 
 	return r
 }
+
+// trustedProxiesFromEnv parses TRUSTED_PROXIES; an empty list trusts no proxy
+func trustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
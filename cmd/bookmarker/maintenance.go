@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultMaintenanceInterval is used when MAINTENANCE_INTERVAL is not set
+const defaultMaintenanceInterval = time.Hour
+
+// webhookDeliveryRetention is how long delivered and failed webhook deliveries stay in the delivery log
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// maintenanceStep purges one feature's stale data and returns how many rows it removed
+type maintenanceStep struct {
+	name  string
+	purge func(now time.Time) (int64, error)
+}
+
+// maintenanceSteps lists the purge steps of the maintenance job; a feature that keeps stale data adds its own step here
+func maintenanceSteps(db *pgxpool.Pool) []maintenanceStep {
+	authService := services.NewAuthService(
+		services.NewUserService(repositories.NewUserRepository(db)),
+		services.NewTokenService(repositories.NewTokenRepository(db)),
+		services.NewRefreshTokenService(repositories.NewRefreshTokenRepository(db)),
+		services.NewSessionService(repositories.NewSessionRepository(db)),
+		nil, nil,
+	)
+	oidcRepo := repositories.NewOIDCRepository(db)
+	linkCodeRepo := repositories.NewLinkCodeRepository(db)
+	emailRepo := repositories.NewEmailRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	return []maintenanceStep{
+		// Expired tokens, abandoned sessions and login challenges
+		{name: "expired tokens", purge: func(time.Time) (int64, error) {
+			return authService.PurgeExpiredTokens()
+		}},
+		{name: "expired OIDC login states", purge: oidcRepo.DeleteExpiredLoginStates},
+		{name: "expired link codes", purge: linkCodeRepo.DeleteExpiredLinkCodes},
+		{name: "unconfirmed email addresses", purge: emailRepo.DeleteExpiredClaims},
+		{name: "old webhook deliveries", purge: func(now time.Time) (int64, error) {
+			return webhookRepo.DeleteFinishedDeliveriesBefore(now.Add(-webhookDeliveryRetention))
+		}},
+	}
+}
+
+// runMaintenance runs every maintenance step every MAINTENANCE_INTERVAL (a Go duration such as "30m") until ctx is cancelled.
+func runMaintenance(ctx context.Context, db *pgxpool.Pool) {
+	interval := defaultMaintenanceInterval
+	if value := os.Getenv("MAINTENANCE_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("Invalid MAINTENANCE_INTERVAL %q, using %s", value, defaultMaintenanceInterval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	steps := maintenanceSteps(db)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, step := range steps {
+			purged, err := step.purge(time.Now())
+			if err != nil {
+				log.Printf("Failed to purge %s: %v", step.name, err)
+			} else if purged > 0 {
+				log.Printf("Purged %d %s", purged, step.name)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bookmarker/internal/dbutil"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// listUsersCommand prints all users with their role and status
+func listUsersCommand() {
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	service := services.NewUserService(repositories.NewUserRepository(db))
+	users, err := service.ListUsers()
+	if err != nil {
+		log.Fatalf("Failed to list users: %v", err)
+	}
+	fmt.Printf("%-6s %-24s %-6s %-9s %s\n", "ID", "USERNAME", "ADMIN", "STATUS", "CREATED")
+	for _, user := range users {
+		status := "active"
+		if user.DisabledAt != nil {
+			status = "disabled"
+		}
+		fmt.Printf("%-6d %-24s %-6t %-9s %s\n", user.ID, user.Username, user.IsAdmin, status, user.CreatedAt.Format("2006-01-02"))
+	}
+}
+
+// resetPasswordCommand sets a new password read from stdin or a prompt and logs the user out everywhere
+func resetPasswordCommand(username string) {
+	password, err := readPassword()
+	if err != nil {
+		log.Fatalf("Failed to read password: %v", err)
+	}
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	service := services.NewUserService(repositories.NewUserRepository(db))
+	user, err := service.ResetPassword(username, password)
+	if err != nil {
+		log.Fatalf("Failed to reset password: %v", err)
+	}
+	fmt.Printf("Password reset for user: ID=%d, Username=%s\n", user.ID, user.Username)
+}
+
+// setUserDisabledCommand disables or re-enables a user's account
+func setUserDisabledCommand(username string, disabled bool) {
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	service := services.NewUserService(repositories.NewUserRepository(db))
+	user, err := service.GetUserByUsername(username)
+	if err != nil {
+		log.Fatalf("Failed to find user: %v", err)
+	}
+	if _, err := service.SetDisabled(user.ID, disabled); err != nil {
+		log.Fatalf("Failed to update user: %v", err)
+	}
+	state := "enabled"
+	if disabled {
+		state = "disabled"
+	}
+	fmt.Printf("User %s: ID=%d, Username=%s\n", state, user.ID, user.Username)
+}
+
+// makeAdminCommand grants the admin role, e.g. to the first user of a new installation
+func makeAdminCommand(username string) {
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	service := services.NewUserService(repositories.NewUserRepository(db))
+	user, err := service.GetUserByUsername(username)
+	if err != nil {
+		log.Fatalf("Failed to find user: %v", err)
+	}
+	if _, err := service.SetAdmin(user.ID, true); err != nil {
+		log.Fatalf("Failed to update user: %v", err)
+	}
+	fmt.Printf("User is now an admin: ID=%d, Username=%s\n", user.ID, user.Username)
+}
+
+// readPassword reads a password from stdin. On a terminal it prompts twice with echo turned off;
+// otherwise it reads the first line, so passwords can be piped in without showing up in the process list.
+func readPassword() (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return readLine(reader)
+	}
+
+	if err := setEcho(false); err == nil {
+		defer setEcho(true)
+	}
+	fmt.Fprint(os.Stderr, "New password: ")
+	password, err := readLine(reader)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := readLine(reader)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if password != repeated {
+		return "", errors.New("passwords do not match")
+	}
+	return password, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setEcho toggles terminal echo with stty, which works on the Unix systems the CLI runs on
+func setEcho(on bool) error {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bookmarker/internal/dbutil"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultTrashRetentionDays is used when neither the argument nor TRASH_RETENTION_DAYS is set
+const defaultTrashRetentionDays = 30
+
+// purgeTrashCommand permanently deletes bookmarks that have been in the trash longer than the retention period.
+// The period comes from the argument, then TRASH_RETENTION_DAYS, then defaultTrashRetentionDays.
+func purgeTrashCommand(daysArg string) {
+	if daysArg == "" {
+		daysArg = os.Getenv("TRASH_RETENTION_DAYS")
+	}
+	days := defaultTrashRetentionDays
+	if daysArg != "" {
+		parsed, err := strconv.Atoi(daysArg)
+		if err != nil || parsed < 0 {
+			log.Fatalf("Invalid retention period %q: must be a number of days", daysArg)
+		}
+		days = parsed
+	}
+
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	bookmarkRepo := repositories.NewBookmarkRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+	purged, err := bookmarkService.PurgeTrash(time.Duration(days) * 24 * time.Hour)
+	if err != nil {
+		log.Fatalf("Failed to purge trash: %v", err)
+	}
+	fmt.Printf("Purged %d bookmarks deleted more than %d days ago.\n", purged, days)
+}
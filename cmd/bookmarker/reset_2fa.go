@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bookmarker/internal/dbutil"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"fmt"
+	"log"
+)
+
+// resetTwoFactorCommand turns two-factor authentication off for a user locked out of their authenticator
+func resetTwoFactorCommand(username string) {
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	service := services.NewUserService(repositories.NewUserRepository(db))
+	user, err := service.ResetTwoFactor(username)
+	if err != nil {
+		log.Fatalf("Failed to reset two-factor authentication: %v", err)
+	}
+	fmt.Printf("Two-factor authentication reset for user: ID=%d, Username=%s\n", user.ID, user.Username)
+}
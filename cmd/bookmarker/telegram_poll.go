@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bookmarker/internal/controllers"
+	"bookmarker/internal/dbutil"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// telegramPollCommand runs the Telegram bot with long polling until interrupted, for deployments
+// Telegram cannot reach with a webhook
+func telegramPollCommand() {
+	db, err := dbutil.OpenPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	log.Println("Polling Telegram for updates...")
+	if err := runTelegramPoll(ctx, db); err != nil {
+		log.Fatalf("Telegram polling stopped: %v", err)
+	}
+	log.Println("Telegram polling stopped")
+}
+
+// runTelegramPoll polls Telegram with the same bot the webhook uses until ctx is cancelled
+func runTelegramPoll(ctx context.Context, db *pgxpool.Pool) error {
+	if os.Getenv("TELEGRAM_BOT_TOKEN") == "" {
+		return errors.New("TELEGRAM_BOT_TOKEN is not set")
+	}
+	return services.NewTelegramPoller(controllers.NewTelegramBot(db), repositories.NewTelegramRepository(db)).Run(ctx)
+}
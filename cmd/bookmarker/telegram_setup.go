@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bookmarker/internal/clients"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// telegramSecretPattern is the format Telegram accepts for a webhook secret_token
+var telegramSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// telegramSetupCommand manages the bot's webhook: "set" registers APP_URL/telegram/listen with
+// TELEGRAM_WEBHOOK_SECRET, "info" prints the current status and "delete" removes it, e.g. before telegram-poll
+func telegramSetupCommand(action string) {
+	client := clients.NewTelegramApiClient()
+	if client.BotToken == "" {
+		log.Fatal("TELEGRAM_BOT_TOKEN is not set")
+	}
+	switch action {
+	case "set":
+		appURL := strings.TrimRight(os.Getenv("APP_URL"), "/")
+		if appURL == "" {
+			log.Fatal("APP_URL is not set")
+		}
+		secret := os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+		if !telegramSecretPattern.MatchString(secret) {
+			log.Fatal("TELEGRAM_WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+		}
+		webhookURL := appURL + "/telegram/listen"
+		if err := client.SetWebhook(webhookURL, secret); err != nil {
+			log.Fatalf("Failed to set webhook: %v", err)
+		}
+		fmt.Printf("Webhook set to %s\n", webhookURL)
+	case "info", "":
+		info, err := client.GetWebhookInfo()
+		if err != nil {
+			log.Fatalf("Failed to get webhook info: %v", err)
+		}
+		if info.URL == "" {
+			fmt.Println("No webhook set; updates can be received with telegram-poll")
+		} else {
+			fmt.Printf("URL:              %s\n", info.URL)
+		}
+		fmt.Printf("Pending updates:  %d\n", info.PendingUpdateCount)
+		if len(info.AllowedUpdates) > 0 {
+			fmt.Printf("Allowed updates:  %s\n", strings.Join(info.AllowedUpdates, ", "))
+		}
+		if info.LastErrorDate != 0 {
+			fmt.Printf("Last error:       %s at %s\n", info.LastErrorMessage, time.Unix(info.LastErrorDate, 0).Format(time.RFC3339))
+		}
+	case "delete":
+		if err := client.DeleteWebhook(false); err != nil {
+			log.Fatalf("Failed to delete webhook: %v", err)
+		}
+		fmt.Println("Webhook deleted")
+	default:
+		log.Fatalf("Unknown telegram-setup action %q; use set, info or delete", action)
+	}
+}
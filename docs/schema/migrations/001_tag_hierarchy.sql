@@ -0,0 +1,11 @@
+-- Hierarchical tags: a tag named "lang/go" points at its parent "lang".
+ALTER TABLE tags ADD COLUMN parent_id INTEGER REFERENCES tags(id) ON DELETE SET NULL;
+CREATE INDEX tags_parent_id ON tags (parent_id);
+
+-- Link existing tags whose direct parent path already exists.
+-- Missing ancestors are created, and the tag linked to them, the next time the tag is saved.
+UPDATE tags child
+SET parent_id = parent.id
+FROM tags parent
+WHERE position('/' IN child.name) > 0
+  AND parent.name = regexp_replace(child.name, '/[^/]*$', '');
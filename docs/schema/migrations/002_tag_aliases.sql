@@ -0,0 +1,8 @@
+-- Alias names that resolve to a canonical tag, e.g. "js" -> "javascript".
+CREATE TABLE tag_aliases (
+    id SERIAL PRIMARY KEY,
+    alias TEXT NOT NULL UNIQUE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
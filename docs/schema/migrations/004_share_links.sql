@@ -0,0 +1,13 @@
+-- Share links: read-only public pages for a collection, tag or saved query
+CREATE TABLE share_links (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE,
+    tag_id INTEGER REFERENCES tags(id) ON DELETE CASCADE,
+    query TEXT,
+    title TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1,10 @@
+-- Bookmark ownership and visibility (private, instance, public).
+ALTER TABLE bookmarks ADD COLUMN user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE bookmarks ADD COLUMN visibility TEXT NOT NULL DEFAULT 'private';
+CREATE INDEX bookmarks_user_id_visibility ON bookmarks (user_id, visibility);
+
+-- Bookmarks saved before ownership existed belong to the first user.
+-- Bookmarks without an owner are not visible to anyone; assign-bookmarks <username> hands them out later.
+UPDATE bookmarks
+SET user_id = (SELECT id FROM users ORDER BY id LIMIT 1)
+WHERE user_id IS NULL;
@@ -0,0 +1,5 @@
+-- Read-later queue: unread, reading, read or archived. NULL keeps a bookmark as a plain reference.
+ALTER TABLE bookmarks ADD COLUMN read_state TEXT;
+ALTER TABLE bookmarks ADD COLUMN read_state_updated_at TIMESTAMPTZ;
+ALTER TABLE bookmarks ADD COLUMN read_at TIMESTAMPTZ;
+CREATE INDEX bookmarks_read_state ON bookmarks (read_state) WHERE read_state IS NOT NULL;
@@ -0,0 +1,13 @@
+-- User-authored Markdown notes, kept apart from the fetched description
+ALTER TABLE bookmarks ADD COLUMN notes TEXT;
+
+-- Quoted highlights on bookmarks, each with an optional note
+CREATE TABLE bookmark_highlights (
+    id SERIAL PRIMARY KEY,
+    bookmark_id INTEGER NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    quote TEXT NOT NULL,
+    note TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX bookmark_highlights_bookmark_id ON bookmark_highlights (bookmark_id);
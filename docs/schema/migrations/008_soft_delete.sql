@@ -0,0 +1,3 @@
+-- Soft delete: deleted bookmarks stay in the trash until purged with `bookmarker purge-trash`
+ALTER TABLE bookmarks ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX bookmarks_deleted_at ON bookmarks (deleted_at) WHERE deleted_at IS NOT NULL;
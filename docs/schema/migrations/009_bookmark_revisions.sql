@@ -0,0 +1,10 @@
+-- Edit history of bookmarks: each update records the changed fields and tags as JSON
+CREATE TABLE bookmark_revisions (
+    id SERIAL PRIMARY KEY,
+    bookmark_id INTEGER NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL,
+    tags JSONB,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX bookmark_revisions_bookmark_id ON bookmark_revisions (bookmark_id);
@@ -0,0 +1,6 @@
+-- Tokens are now stored as SHA-256 hashes. Existing plaintext tokens cannot be converted
+-- without keeping them readable, so they are deleted and every user has to log in again.
+DELETE FROM tokens;
+DELETE FROM refresh_tokens;
+ALTER TABLE tokens RENAME COLUMN token TO token_hash;
+ALTER TABLE refresh_tokens RENAME COLUMN refresh_token TO refresh_token_hash;
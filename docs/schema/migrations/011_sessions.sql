@@ -0,0 +1,21 @@
+-- Login sessions with device metadata. Tokens issued before sessions existed have none
+-- to belong to, so they are deleted and every user has to log in again.
+CREATE TABLE sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX sessions_user_id ON sessions (user_id);
+
+DELETE FROM tokens;
+DELETE FROM refresh_tokens;
+ALTER TABLE tokens ADD COLUMN session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE;
+ALTER TABLE refresh_tokens ADD COLUMN session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE;
+-- Exchanged refresh tokens are kept until they expire to detect reuse
+ALTER TABLE refresh_tokens ADD COLUMN used_at TIMESTAMPTZ;
+CREATE INDEX tokens_expires_at ON tokens (expires_at);
+CREATE INDEX refresh_tokens_expires_at ON refresh_tokens (expires_at);
+CREATE INDEX refresh_tokens_session_id ON refresh_tokens (session_id);
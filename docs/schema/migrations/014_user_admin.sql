@@ -0,0 +1,3 @@
+-- Admin role and account disabling
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMPTZ;
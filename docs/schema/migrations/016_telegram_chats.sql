@@ -0,0 +1,15 @@
+-- Telegram chats linked to users; messages from other chats are rejected
+CREATE TABLE telegram_chats (
+    chat_id BIGINT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX telegram_chats_user_id ON telegram_chats (user_id);
+
+-- One-time codes a user sends to the bot with /link
+CREATE TABLE telegram_link_codes (
+    code_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,6 @@
+-- Next update to fetch when the bot runs in long polling mode, per bot
+CREATE TABLE telegram_poll_offsets (
+    bot_id BIGINT PRIMARY KEY,
+    next_offset BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
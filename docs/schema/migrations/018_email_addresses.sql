@@ -0,0 +1,14 @@
+-- Sender addresses users save bookmarks from by email; mail from other senders is rejected.
+-- An address must be confirmed with a mailed code first; several users may claim it until one of them confirms it.
+CREATE TABLE email_addresses (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address TEXT NOT NULL,
+    verified_at TIMESTAMPTZ,
+    verification_code_hash TEXT UNIQUE,
+    verification_expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX email_addresses_user_id ON email_addresses (user_id);
+CREATE UNIQUE INDEX email_addresses_user_address ON email_addresses (user_id, address);
+CREATE UNIQUE INDEX email_addresses_verified_address ON email_addresses (address) WHERE verified_at IS NOT NULL;
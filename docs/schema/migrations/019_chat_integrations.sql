@@ -0,0 +1,28 @@
+-- Link codes now link Slack and Discord accounts as well as Telegram chats
+ALTER TABLE telegram_link_codes RENAME TO link_codes;
+-- Link codes are issued for one provider, so a code meant for Telegram cannot link a Slack or Discord account.
+-- Codes issued before this only link Telegram chats.
+ALTER TABLE link_codes ADD COLUMN provider TEXT NOT NULL DEFAULT 'telegram';
+ALTER TABLE link_codes ALTER COLUMN provider DROP DEFAULT;
+
+-- Slack and Discord accounts linked to users; slash commands from other accounts are rejected.
+-- account_id is "<team>:<user>" for Slack and the user ID for Discord.
+CREATE TABLE chat_accounts (
+    provider TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (provider, account_id)
+);
+CREATE INDEX chat_accounts_user_id ON chat_accounts (user_id);
+
+-- Signed inbound webhooks (POST /hooks/:id); the secret is kept to verify HMAC signatures
+CREATE TABLE inbound_hooks (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ
+);
+CREATE INDEX inbound_hooks_user_id ON inbound_hooks (user_id);
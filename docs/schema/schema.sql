@@ -1,16 +1,46 @@
+-- Users table
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    disabled_at TIMESTAMPTZ,
+    totp_secret TEXT,
+    totp_enabled_at TIMESTAMPTZ,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
 CREATE TABLE bookmarks (
     id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
     title TEXT NOT NULL,
     description TEXT,
     thumbnail TEXT,
     url TEXT,
+    visibility TEXT NOT NULL DEFAULT 'private',
+    notes TEXT,
+    read_state TEXT,
+    read_state_updated_at TIMESTAMPTZ,
+    read_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ,
-    updated_at TIMESTAMPTZ
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
 );
 
 CREATE TABLE tags (
     id SERIAL PRIMARY KEY,
     name TEXT NOT NULL,
+    parent_id INTEGER REFERENCES tags(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE tag_aliases (
+    id SERIAL PRIMARY KEY,
+    alias TEXT NOT NULL UNIQUE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
     created_at TIMESTAMPTZ,
     updated_at TIMESTAMPTZ
 );
@@ -21,20 +51,74 @@ CREATE TABLE bookmarks_tags (
     created_at TIMESTAMPTZ
 );
 
--- Users table
-CREATE TABLE users (
+-- Quoted highlights on bookmarks, each with an optional note
+CREATE TABLE bookmark_highlights (
     id SERIAL PRIMARY KEY,
-    username TEXT NOT NULL UNIQUE,
-    password_hash TEXT NOT NULL,
+    bookmark_id INTEGER NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    quote TEXT NOT NULL,
+    note TEXT,
     created_at TIMESTAMPTZ,
     updated_at TIMESTAMPTZ
 );
 
--- Access tokens table
-CREATE TABLE tokens (
+-- Edit history of bookmarks: changed fields and tags, kept as JSON
+CREATE TABLE bookmark_revisions (
+    id SERIAL PRIMARY KEY,
+    bookmark_id INTEGER NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL,
+    tags JSONB,
+    created_at TIMESTAMPTZ
+);
+
+-- Collections: named, manually ordered groups of bookmarks
+CREATE TABLE collections (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE collections_bookmarks (
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    bookmark_id INTEGER NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (collection_id, bookmark_id)
+);
+
+-- Share links: read-only public pages for a collection, tag or saved query
+CREATE TABLE share_links (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     token TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE,
+    tag_id INTEGER REFERENCES tags(id) ON DELETE CASCADE,
+    query TEXT,
+    title TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+-- Login sessions; each access and refresh token belongs to one
+CREATE TABLE sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ NOT NULL
+);
+
+-- Access tokens table; only the SHA-256 hash of each token is stored
+CREATE TABLE tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
     expires_at TIMESTAMPTZ NOT NULL,
     created_at TIMESTAMPTZ,
     updated_at TIMESTAMPTZ
@@ -44,11 +128,184 @@ CREATE TABLE tokens (
 CREATE TABLE refresh_tokens (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    refresh_token TEXT NOT NULL UNIQUE,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    refresh_token_hash TEXT NOT NULL UNIQUE,
     expires_at TIMESTAMPTZ NOT NULL,
     created_at TIMESTAMPTZ,
-    updated_at TIMESTAMPTZ
+    updated_at TIMESTAMPTZ,
+    used_at TIMESTAMPTZ
+);
+
+-- Single-use recovery codes for two-factor authentication, stored as SHA-256 hashes
+CREATE TABLE user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Logins waiting for their second factor
+CREATE TABLE two_factor_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Failed login counters per username and per IP address
+CREATE TABLE login_throttles (
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, key)
+);
+
+-- Security audit log
+CREATE TABLE audit_log (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    event TEXT NOT NULL,
+    username TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
 );
 
 CREATE INDEX bookmarks_text_search_index ON bookmarks (title, description, url);
-CREATE INDEX tags_search_name ON tags (name);
\ No newline at end of file
+CREATE INDEX tags_search_name ON tags (name);
+CREATE INDEX tags_parent_id ON tags (parent_id);
+CREATE INDEX collections_user_id ON collections (user_id);
+CREATE INDEX bookmarks_user_id_visibility ON bookmarks (user_id, visibility);
+CREATE INDEX bookmarks_read_state ON bookmarks (read_state) WHERE read_state IS NOT NULL;
+CREATE INDEX bookmark_highlights_bookmark_id ON bookmark_highlights (bookmark_id);
+CREATE INDEX bookmarks_deleted_at ON bookmarks (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX bookmark_revisions_bookmark_id ON bookmark_revisions (bookmark_id);
+CREATE INDEX sessions_user_id ON sessions (user_id);
+CREATE INDEX tokens_expires_at ON tokens (expires_at);
+CREATE INDEX refresh_tokens_expires_at ON refresh_tokens (expires_at);
+CREATE INDEX refresh_tokens_session_id ON refresh_tokens (session_id);
+CREATE INDEX audit_log_created_at ON audit_log (created_at);
+CREATE INDEX user_recovery_codes_user_id ON user_recovery_codes (user_id, code_hash);
+
+-- Accounts at an OpenID Connect provider linked to users
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_login_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (issuer, subject)
+);
+CREATE INDEX user_identities_user_id ON user_identities (user_id);
+
+-- Logins sent to the identity provider and waiting for its callback
+CREATE TABLE oidc_login_states (
+    state_hash TEXT PRIMARY KEY,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    redirect_path TEXT NOT NULL,
+    link_user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Telegram chats linked to users; messages from other chats are rejected
+CREATE TABLE telegram_chats (
+    chat_id BIGINT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX telegram_chats_user_id ON telegram_chats (user_id);
+
+-- One-time codes that link a Telegram chat (sent to the bot with /link) or a Slack or Discord account
+-- (with /bookmarks link) to a user; a code only links the provider it was issued for
+CREATE TABLE link_codes (
+    code_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Next update to fetch when the bot runs in long polling mode, per bot
+CREATE TABLE telegram_poll_offsets (
+    bot_id BIGINT PRIMARY KEY,
+    next_offset BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Sender addresses users save bookmarks from by email; mail from other senders is rejected.
+-- An address must be confirmed with a mailed code first; several users may claim it until one of them confirms it.
+CREATE TABLE email_addresses (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address TEXT NOT NULL,
+    verified_at TIMESTAMPTZ,
+    verification_code_hash TEXT UNIQUE,
+    verification_expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX email_addresses_user_id ON email_addresses (user_id);
+CREATE UNIQUE INDEX email_addresses_user_address ON email_addresses (user_id, address);
+CREATE UNIQUE INDEX email_addresses_verified_address ON email_addresses (address) WHERE verified_at IS NOT NULL;
+
+-- Slack and Discord accounts linked to users; slash commands from other accounts are rejected.
+-- account_id is "<team>:<user>" for Slack and the user ID for Discord.
+CREATE TABLE chat_accounts (
+    provider TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (provider, account_id)
+);
+CREATE INDEX chat_accounts_user_id ON chat_accounts (user_id);
+
+-- Signed inbound webhooks (POST /hooks/:id); the secret is kept to verify HMAC signatures
+CREATE TABLE inbound_hooks (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ
+);
+CREATE INDEX inbound_hooks_user_id ON inbound_hooks (user_id);
+
+-- Outgoing webhooks users subscribe to bookmark and tag events with
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX webhooks_user_id ON webhooks (user_id);
+
+-- Outbox and delivery log: a row is written together with the change it reports and
+-- sent by the dispatcher, which retries with backoff until it is delivered or fails for good
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_attempt_at TIMESTAMPTZ,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ
+);
+CREATE INDEX webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id DESC);
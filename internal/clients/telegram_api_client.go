@@ -1,38 +1,171 @@
 package clients
 
 import (
+	"bookmarker/internal/models"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// defaultTelegramApiURL is the Bot API server used unless TELEGRAM_API_URL points elsewhere,
+// e.g. at a local Bot API server or a fake one for testing
+const defaultTelegramApiURL = "https://api.telegram.org"
+
+// ErrTelegramWebhookActive is returned by GetUpdates while a webhook is set; Telegram allows only one delivery mode.
+var ErrTelegramWebhookActive = errors.New("telegram webhook is active; delete it before polling")
+
 type TelegramApiClient struct {
 	BotToken string
+	BaseURL  string
+	// HTTPClient must allow requests longer than the long polling timeout
+	HTTPClient *http.Client
 }
 
 func NewTelegramApiClient() *TelegramApiClient {
+	baseURL := defaultTelegramApiURL
+	if v := os.Getenv("TELEGRAM_API_URL"); v != "" {
+		baseURL = strings.TrimRight(v, "/")
+	}
 	return &TelegramApiClient{
-		BotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		BotToken:   os.Getenv("TELEGRAM_BOT_TOKEN"),
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 90 * time.Second},
 	}
 }
 
+// BotID returns the bot's numeric ID, the part of the token before the colon
+func (c *TelegramApiClient) BotID() int64 {
+	id, _ := strconv.ParseInt(strings.SplitN(c.BotToken, ":", 2)[0], 10, 64)
+	return id
+}
+
 // SendMessage sends a message to a Telegram chat
 func (c *TelegramApiClient) SendMessage(chatID int64, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", c.BotToken)
-	payload := map[string]interface{}{
+	return c.call("sendMessage", map[string]interface{}{
 		"chat_id": chatID,
 		"text":    text,
+	})
+}
+
+// SendHTMLMessage sends a message formatted with Telegram's HTML subset, without link previews
+func (c *TelegramApiClient) SendHTMLMessage(chatID int64, html string) error {
+	return c.SendHTMLMessageWithMarkup(chatID, html, nil)
+}
+
+// SendHTMLMessageWithMarkup sends an HTML message with reply markup such as an inline keyboard or a forced reply
+func (c *TelegramApiClient) SendHTMLMessageWithMarkup(chatID int64, html string, markup interface{}) error {
+	payload := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     html,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	if markup != nil {
+		payload["reply_markup"] = markup
 	}
+	return c.call("sendMessage", payload)
+}
+
+// EditMessageText replaces the text and inline keyboard of a message the bot sent; a nil keyboard removes it
+func (c *TelegramApiClient) EditMessageText(chatID int64, messageID int64, html string, keyboard *models.TelegramInlineKeyboardMarkup) error {
+	payload := map[string]interface{}{
+		"chat_id":                  chatID,
+		"message_id":               messageID,
+		"text":                     html,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	if keyboard != nil {
+		payload["reply_markup"] = keyboard
+	}
+	return c.call("editMessageText", payload)
+}
+
+// AnswerCallbackQuery stops the button's loading indicator, showing text as a short notification
+func (c *TelegramApiClient) AnswerCallbackQuery(callbackQueryID string, text string) error {
+	return c.call("answerCallbackQuery", map[string]interface{}{
+		"callback_query_id": callbackQueryID,
+		"text":              text,
+	})
+}
+
+// GetUpdates long-polls for updates starting at offset, waiting up to timeout seconds for new ones.
+// Passing the ID after the last handled update confirms everything before it to Telegram.
+func (c *TelegramApiClient) GetUpdates(offset int64, timeout int) ([]models.TelegramUpdate, error) {
+	var updates []models.TelegramUpdate
+	err := c.callWithResult("getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": TelegramAllowedUpdates,
+	}, &updates)
+	return updates, err
+}
+
+// TelegramAllowedUpdates are the update types the bot handles, whether delivered by webhook or polling
+var TelegramAllowedUpdates = []string{"message", "edited_message", "channel_post", "edited_channel_post", "callback_query"}
+
+// SetWebhook points Telegram at url. Telegram sends secretToken in the X-Telegram-Bot-Api-Secret-Token header of every delivery.
+func (c *TelegramApiClient) SetWebhook(url string, secretToken string) error {
+	return c.callWithResult("setWebhook", map[string]interface{}{
+		"url":             url,
+		"secret_token":    secretToken,
+		"allowed_updates": TelegramAllowedUpdates,
+	}, new(bool))
+}
+
+// GetWebhookInfo returns the current webhook status; the URL is empty when no webhook is set
+func (c *TelegramApiClient) GetWebhookInfo() (models.TelegramWebhookInfo, error) {
+	var info models.TelegramWebhookInfo
+	err := c.callWithResult("getWebhookInfo", map[string]interface{}{}, &info)
+	return info, err
+}
+
+// DeleteWebhook removes the webhook so updates can be polled; dropPending discards updates not delivered yet
+func (c *TelegramApiClient) DeleteWebhook(dropPending bool) error {
+	return c.callWithResult("deleteWebhook", map[string]interface{}{
+		"drop_pending_updates": dropPending,
+	}, new(bool))
+}
+
+// call invokes a Bot API method with a JSON payload
+func (c *TelegramApiClient) call(method string, payload map[string]interface{}) error {
+	return c.callWithResult(method, payload, nil)
+}
+
+// callWithResult invokes a Bot API method and decodes the result field of the response into result, if not nil
+func (c *TelegramApiClient) callWithResult(method string, payload map[string]interface{}, result interface{}) error {
+	url := fmt.Sprintf("%s/bot%s/%s", c.BaseURL, c.BotToken, method)
 	body, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := c.HTTPClient.Post(url, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict && method == "getUpdates" {
+		return ErrTelegramWebhookActive
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram sendMessage failed: %s", resp.Status)
+		return fmt.Errorf("telegram %s failed: %s", method, resp.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram %s failed: %s", method, envelope.Description)
 	}
-	return nil
+	return json.Unmarshal(envelope.Result, result)
 }
\ No newline at end of file
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController manages user accounts; its routes are guarded by AdminMiddleware
+type AdminController struct {
+	UserService *services.UserService
+}
+
+func NewAdminController(userService *services.UserService) *AdminController {
+	return &AdminController{UserService: userService}
+}
+
+// ListUsers handles GET /admin/users
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	users, err := ac.UserService.ListUsers()
+	if err != nil {
+		log.Printf("Failed to list users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// DisableUser handles POST /admin/users/:id/disable. The user is logged out everywhere.
+func (ac *AdminController) DisableUser(c *gin.Context) {
+	ac.setDisabled(c, true)
+}
+
+// EnableUser handles POST /admin/users/:id/enable
+func (ac *AdminController) EnableUser(c *gin.Context) {
+	ac.setDisabled(c, false)
+}
+
+func (ac *AdminController) setDisabled(c *gin.Context, disabled bool) {
+	id, ok := ac.targetUserID(c)
+	if !ok {
+		return
+	}
+	user, err := ac.UserService.SetDisabled(id, disabled)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update user %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// DeleteUser handles DELETE /admin/users/:id. Their bookmarks and collections are deleted with them
+// unless ?transfer_to=<user id> names a user to hand them over to.
+func (ac *AdminController) DeleteUser(c *gin.Context) {
+	id, ok := ac.targetUserID(c)
+	if !ok {
+		return
+	}
+	var transferTo *int64
+	if param := c.Query("transfer_to"); param != "" {
+		target, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer_to user ID"})
+			return
+		}
+		transferTo = &target
+	}
+	err := ac.UserService.DeleteUser(id, transferTo)
+	switch {
+	case errors.Is(err, repositories.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case errors.Is(err, services.ErrInvalidTransferTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to delete user %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// targetUserID parses the :id parameter, refusing the admin's own account so they cannot lock themselves out
+func (ac *AdminController) targetUserID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	if currentID, _ := currentUserID(c); int64(currentID) == id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrCannotDeleteSelf.Error()})
+		return 0, false
+	}
+	return id, true
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"bookmarker/internal/models"
 	"bookmarker/internal/repositories"
 	"bookmarker/internal/services"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -21,6 +23,12 @@ func NewBookmarksController(db *pgxpool.Pool) *BookmarksController {
 }
 
 func (bc *BookmarksController) GetBookmarks(c *gin.Context) {
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
     // Initialize the repository and service
     bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
     tagRepo := repositories.NewTagRepository(bc.DB)
@@ -41,8 +49,42 @@ func (bc *BookmarksController) GetBookmarks(c *gin.Context) {
         limit = 10
     }
 
+    // Filter by collection if requested, keeping the collection's manual order
+    if collectionParam := c.Query("collection"); collectionParam != "" {
+        collectionID, err := strconv.Atoi(collectionParam)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+            return
+        }
+        collectionService := services.NewCollectionService(repositories.NewCollectionRepository(bc.DB), bookmarkRepo, tagRepo)
+        bookmarks, err := collectionService.ListBookmarksWithTags(userID, collectionID, page, limit)
+        if err != nil {
+            respondCollectionError(c, err, "Failed to list bookmarks")
+            return
+        }
+        c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks})
+        return
+    }
+
+    // Only the user's own bookmarks, optionally narrowed to one visibility
+    filter := services.ViewerFilter(userID)
+    if visibility := c.Query("visibility"); visibility != "" {
+        if !models.IsValidVisibility(visibility) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidVisibility.Error()})
+            return
+        }
+        filter.Visibilities = []string{visibility}
+    }
+    if state := c.Query("state"); state != "" {
+        if !models.IsValidReadState(state) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidReadState.Error()})
+            return
+        }
+        filter.ReadStates = []string{state}
+    }
+
     // Fetch bookmarks with tags using the service layer
-    bookmarks, err := bookmarkService.ListBookmarksWithTags(page, limit)
+    bookmarks, err := bookmarkService.ListBookmarksWithTags(filter, page, limit)
     if err != nil {
         log.Printf("Failed to list bookmarks: %v", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list bookmarks"})
@@ -62,6 +104,14 @@ func (bc *BookmarksController) CreateBookmark(c *gin.Context) {
         Description string   `json:"description"`
         Thumbnail   string   `json:"thumbnail"`
         Tags        []string `json:"tags"`
+        Visibility  string   `json:"visibility"`
+        Notes       string   `json:"notes"`
+    }
+
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
     }
 
     // Bind JSON input
@@ -71,13 +121,27 @@ func (bc *BookmarksController) CreateBookmark(c *gin.Context) {
     }
 
     // Initialize the repositories and service
-    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
     tagRepo := repositories.NewTagRepository(bc.DB)
-    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+    bookmarkService := services.NewBookmarkServiceWithWebhooks(bc.DB)
     
 
     // Create the bookmark with tags
-    bookmark, err := bookmarkService.CreateBookmarkWithTags(input.URL, input.Title, input.Description, input.Thumbnail, input.Tags, time.Now())
+    ownerID := int64(userID)
+    bookmark, err := bookmarkService.CreateBookmarkWithTags(services.BookmarkInput{
+        URL:         input.URL,
+        Title:       input.Title,
+        Description: input.Description,
+        Thumbnail:   input.Thumbnail,
+        Tags:        input.Tags,
+        CreatedAt:   time.Now(),
+        UserID:      &ownerID,
+        Visibility:  input.Visibility,
+        Notes:       input.Notes,
+    })
+    if errors.Is(err, services.ErrInvalidVisibility) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
     if err != nil {
         log.Printf("Failed to create bookmark: %v", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bookmark"})
@@ -113,14 +177,35 @@ func (bc *BookmarksController) GetBookmark(c *gin.Context) {
         return
     }
 
-    // Fetch the bookmark by ID with tags
-    bookmark, err := bookmarkService.GetBookmarkWithTags(bookmarkID)
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    // Fetch the bookmark by ID with tags, if the user may see it
+    bookmark, err := bookmarkService.GetVisibleBookmarkWithTags(userID, bookmarkID)
+    if errors.Is(err, services.ErrBookmarkNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
     if err != nil {
         log.Printf("Failed to fetch bookmark: %v", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmark"})
         return
     }
 
+    // Highlights are personal, like notes, so only the owner sees them
+    if bookmark.UserID != nil && *bookmark.UserID == int64(userID) {
+        highlightRepo := repositories.NewHighlightRepository(bc.DB)
+        bookmark.Highlights, err = highlightRepo.ListHighlights(bookmarkID)
+        if err != nil {
+            log.Printf("Failed to fetch highlights: %v", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmark"})
+            return
+        }
+    }
+
     // Respond with the bookmark wrapped in the 'bookmark' key
     c.JSON(http.StatusOK, gin.H{"bookmark": bookmark})
 }
@@ -140,11 +225,22 @@ func (bc *BookmarksController) UpdateBookmark(c *gin.Context) {
         Description *string   `json:"description"`
         Thumbnail   *string   `json:"thumbnail"`
         Tags        *[]string `json:"tags"`
+        Visibility  *string   `json:"visibility"`
+        ReadState   *string   `json:"read_state"`
+        Notes       *string   `json:"notes"`
     }
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
         return
     }
+    if input.ReadState != nil && *input.ReadState != "" && !models.IsValidReadState(*input.ReadState) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidReadState.Error()})
+        return
+    }
+    if input.Visibility != nil && !models.IsValidVisibility(*input.Visibility) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidVisibility.Error()})
+        return
+    }
 
     updateFields := make(map[string]interface{})
     if input.URL != nil {
@@ -159,17 +255,36 @@ func (bc *BookmarksController) UpdateBookmark(c *gin.Context) {
     if input.Thumbnail != nil {
         updateFields["thumbnail"] = *input.Thumbnail
     }
+    if input.Visibility != nil {
+        updateFields["visibility"] = *input.Visibility
+    }
+    if input.Notes != nil {
+        updateFields["notes"] = *input.Notes
+    }
 
-    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
-    tagRepo := repositories.NewTagRepository(bc.DB)
-    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+    bookmarkService := services.NewBookmarkServiceWithWebhooks(bc.DB)
+
+    // Only the owner may change a bookmark
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
 
     var updatedBookmark interface{}
     if input.Tags != nil {
         // Update tags as well
-        updatedBookmark, err = bookmarkService.UpdateBookmarkWithTags(bookmarkID, updateFields, *input.Tags)
+        updatedBookmark, err = bookmarkService.UpdateBookmarkWithTags(userID, bookmarkID, updateFields, *input.Tags)
     } else {
-        updatedBookmark, err = bookmarkService.UpdateBookmark(bookmarkID, updateFields)
+        updatedBookmark, err = bookmarkService.UpdateBookmark(userID, bookmarkID, updateFields)
+    }
+    if err == nil && input.ReadState != nil {
+        // The reading state keeps its own timestamps, so it goes through the service
+        updatedBookmark, err = bookmarkService.SetReadState(bookmarkID, *input.ReadState)
     }
     if err != nil {
         log.Printf("Failed to update bookmark: %v", err)
@@ -188,9 +303,16 @@ func (bc *BookmarksController) DeleteBookmark(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
         return
     }
-    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
-    tagRepo := repositories.NewTagRepository(bc.DB)
-    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+    bookmarkService := services.NewBookmarkServiceWithWebhooks(bc.DB)
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
     err = bookmarkService.DeleteBookmark(bookmarkID)
     if err != nil {
         log.Printf("Failed to delete bookmark: %v", err)
@@ -198,4 +320,339 @@ func (bc *BookmarksController) DeleteBookmark(c *gin.Context) {
         return
     }
     c.Status(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// GetSharedBookmarks handles GET /bookmarks/shared, the team-wide feed of
+// other users' bookmarks shared with the instance
+func (bc *BookmarksController) GetSharedBookmarks(c *gin.Context) {
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+    page, limit := paginationParams(c)
+
+    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
+    tagRepo := repositories.NewTagRepository(bc.DB)
+    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+
+    bookmarks, err := bookmarkService.ListSharedWithInstance(userID, page, limit)
+    if err != nil {
+        log.Printf("Failed to list shared bookmarks: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list shared bookmarks"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks})
+}
+
+// SetReadState handles PUT /bookmarks/:id/read-state and moves a bookmark through the read-later queue.
+// An empty state removes the bookmark from the queue.
+func (bc *BookmarksController) SetReadState(c *gin.Context) {
+    bookmarkID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+        return
+    }
+    var input struct {
+        State string `json:"state"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+        return
+    }
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkService := services.NewBookmarkServiceWithWebhooks(bc.DB)
+
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
+    bookmark, err := bookmarkService.SetReadState(bookmarkID, input.State)
+    if errors.Is(err, services.ErrInvalidReadState) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to set read state: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set read state"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"bookmark": bookmark})
+}
+
+// GetNextUnread handles GET /bookmarks/next-unread and returns the oldest unread bookmark
+func (bc *BookmarksController) GetNextUnread(c *gin.Context) {
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
+    tagRepo := repositories.NewTagRepository(bc.DB)
+    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+
+    bookmark, err := bookmarkService.GetNextUnread(userID)
+    if errors.Is(err, services.ErrBookmarkNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "No unread bookmarks"})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to fetch next unread bookmark: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch next unread bookmark"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"bookmark": bookmark})
+}
+
+// CreateHighlight handles POST /bookmarks/:id/highlights and stores a quoted passage with an optional note
+func (bc *BookmarksController) CreateHighlight(c *gin.Context) {
+    bookmarkID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+        return
+    }
+    var input struct {
+        Quote string  `json:"quote" binding:"required"`
+        Note  *string `json:"note"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+        return
+    }
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
+    tagRepo := repositories.NewTagRepository(bc.DB)
+    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
+
+    highlight, err := repositories.NewHighlightRepository(bc.DB).CreateHighlight(bookmarkID, input.Quote, input.Note)
+    if err != nil {
+        log.Printf("Failed to create highlight: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create highlight"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"highlight": highlight})
+}
+
+// DeleteHighlight handles DELETE /bookmarks/:id/highlights/:highlightId
+func (bc *BookmarksController) DeleteHighlight(c *gin.Context) {
+    bookmarkID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+        return
+    }
+    highlightID, err := strconv.Atoi(c.Param("highlightId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid highlight ID"})
+        return
+    }
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
+    tagRepo := repositories.NewTagRepository(bc.DB)
+    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
+
+    if err := repositories.NewHighlightRepository(bc.DB).DeleteHighlight(bookmarkID, highlightID); err != nil {
+        log.Printf("Failed to delete highlight: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete highlight"})
+        return
+    }
+    c.Status(http.StatusNoContent)
+}
+
+// GetTrash handles GET /trash and lists the user's deleted bookmarks
+func (bc *BookmarksController) GetTrash(c *gin.Context) {
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+    page, limit := paginationParams(c)
+
+    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
+    tagRepo := repositories.NewTagRepository(bc.DB)
+    bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+
+    bookmarks, err := bookmarkService.ListTrash(userID, page, limit)
+    if err != nil {
+        log.Printf("Failed to list trash: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list trash"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks})
+}
+
+// RestoreBookmark handles POST /bookmarks/:id/restore and takes a bookmark out of the trash
+func (bc *BookmarksController) RestoreBookmark(c *gin.Context) {
+    bookmarkID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+        return
+    }
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkService := services.NewBookmarkServiceWithWebhooks(bc.DB)
+
+    bookmark, err := bookmarkService.RestoreBookmark(userID, bookmarkID)
+    if errors.Is(err, services.ErrBookmarkNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found in trash"})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to restore bookmark: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore bookmark"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"bookmark": bookmark})
+}
+
+// GetBookmarkHistory handles GET /bookmarks/:id/history and lists the bookmark's revisions, newest first
+func (bc *BookmarksController) GetBookmarkHistory(c *gin.Context) {
+    bookmarkID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+        return
+    }
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkRepo := repositories.NewBookmarkRepository(bc.DB)
+    tagRepo := repositories.NewTagRepository(bc.DB)
+    revisionRepo := repositories.NewRevisionRepository(bc.DB)
+    bookmarkService := services.NewBookmarkServiceWithHistory(bookmarkRepo, tagRepo, revisionRepo)
+
+    // History includes notes, so only the owner may read it
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
+    revisions, err := bookmarkService.ListRevisions(bookmarkID)
+    if err != nil {
+        log.Printf("Failed to list bookmark history: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list bookmark history"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RevertBookmark handles POST /bookmarks/:id/history/:revisionId/revert and
+// puts the bookmark back the way it was before that revision
+func (bc *BookmarksController) RevertBookmark(c *gin.Context) {
+    bookmarkID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+        return
+    }
+    revisionID, err := strconv.Atoi(c.Param("revisionId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+        return
+    }
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    bookmarkService := services.NewBookmarkServiceWithWebhooks(bc.DB)
+
+    if err := bookmarkService.EnsureCanEdit(userID, bookmarkID); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
+    bookmark, err := bookmarkService.RevertToRevision(userID, bookmarkID, revisionID)
+    if errors.Is(err, services.ErrRevisionNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to revert bookmark: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert bookmark"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"bookmark": bookmark})
+}
+
+// BulkBookmarks handles POST /bookmarks/bulk and applies one operation to bookmarks selected by ID or search query.
+// Everything runs in one transaction; with dry_run the changes are rolled back and only the results are returned.
+func (bc *BookmarksController) BulkBookmarks(c *gin.Context) {
+    userID, ok := currentUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var input struct {
+        IDs          []int    `json:"ids"`
+        Query        string   `json:"query"`
+        Operation    string   `json:"operation" binding:"required"`
+        Tags         []string `json:"tags"`
+        ReadState    string   `json:"read_state"`
+        CollectionID int      `json:"collection_id"`
+        DryRun       bool     `json:"dry_run"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+        return
+    }
+
+    bulkService := services.NewBulkService(bc.DB)
+    result, err := bulkService.Run(userID, services.BulkRequest{
+        IDs:          input.IDs,
+        Query:        input.Query,
+        Operation:    input.Operation,
+        Tags:         input.Tags,
+        ReadState:    input.ReadState,
+        CollectionID: input.CollectionID,
+        DryRun:       input.DryRun,
+    })
+    switch {
+    case errors.Is(err, services.ErrInvalidBulkOperation), errors.Is(err, services.ErrBulkSelection),
+        errors.Is(err, services.ErrTooManyBulkItems), errors.Is(err, services.ErrInvalidReadState):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrCollectionNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+        return
+    case err != nil:
+        log.Printf("Failed to run bulk operation: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run bulk operation"})
+        return
+    }
+
+    // A failed item rolls back the whole request; the results say which one
+    if result.Failed > 0 {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"result": result})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"result": result})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// discordMessageLimit is the most characters Discord shows in one message
+const discordMessageLimit = 2000
+
+// discordEphemeralFlag shows a reply only to the user who ran the command
+const discordEphemeralFlag = 64
+
+const chatCommandFailed = "Something went wrong, please try again."
+
+type ChatCommandsController struct {
+	DB *pgxpool.Pool
+}
+
+func NewChatCommandsController(db *pgxpool.Pool) *ChatCommandsController {
+	return &ChatCommandsController{DB: db}
+}
+
+func (cc *ChatCommandsController) commandService() *services.ChatCommandService {
+	bookmarkService := services.NewBookmarkServiceWithWebhooks(cc.DB)
+	return services.NewChatCommandService(bookmarkService, repositories.NewChatAccountRepository(cc.DB))
+}
+
+// SlackCommand handles POST /slack/commands for the /bookmark and /bookmarks slash commands of a Slack app.
+// Requests are signed with SLACK_SIGNING_SECRET; replies are ephemeral.
+func (cc *ChatCommandsController) SlackCommand(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxHookBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		return
+	}
+	if !services.VerifySlackSignature(os.Getenv("SLACK_SIGNING_SECRET"), c.GetHeader("X-Slack-Request-Timestamp"), body, c.GetHeader("X-Slack-Signature"), time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil || form.Get("user_id") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slash command"})
+		return
+	}
+	reply, err := cc.commandService().Handle(services.ChatCommand{
+		Provider:  models.ChatProviderSlack,
+		AccountID: form.Get("team_id") + ":" + form.Get("user_id"),
+		Name:      strings.TrimPrefix(form.Get("command"), "/"),
+		Text:      form.Get("text"),
+	})
+	if err != nil {
+		log.Printf("Failed to handle Slack command %q: %v", form.Get("command"), err)
+		reply = chatCommandFailed
+	}
+	c.JSON(http.StatusOK, gin.H{"response_type": "ephemeral", "text": reply})
+}
+
+// DiscordInteraction handles POST /discord/interactions, the interactions endpoint of a Discord application
+// with /bookmark and /bookmarks commands. Requests are verified with DISCORD_PUBLIC_KEY; replies are ephemeral.
+func (cc *ChatCommandsController) DiscordInteraction(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxHookBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		return
+	}
+	if !services.VerifyDiscordSignature(os.Getenv("DISCORD_PUBLIC_KEY"), c.GetHeader("X-Signature-Timestamp"), body, c.GetHeader("X-Signature-Ed25519"), time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid request signature"})
+		return
+	}
+	var interaction models.DiscordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interaction"})
+		return
+	}
+	switch interaction.Type {
+	case models.DiscordInteractionPing:
+		c.JSON(http.StatusOK, gin.H{"type": 1})
+		return
+	case models.DiscordInteractionApplicationCommand:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported interaction type"})
+		return
+	}
+	user := interaction.InvokingUser()
+	if user == nil || user.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interaction"})
+		return
+	}
+	reply, err := cc.commandService().Handle(services.ChatCommand{
+		Provider:  models.ChatProviderDiscord,
+		AccountID: user.ID,
+		Name:      interaction.Data.Name,
+		Text:      services.DiscordCommandText(interaction.Data.Options),
+	})
+	if err != nil {
+		log.Printf("Failed to handle Discord command %q: %v", interaction.Data.Name, err)
+		reply = chatCommandFailed
+	}
+	if utf8.RuneCountInString(reply) > discordMessageLimit {
+		reply = string([]rune(reply)[:discordMessageLimit-1]) + "…"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"type": 4,
+		"data": gin.H{"content": reply, "flags": discordEphemeralFlag},
+	})
+}
+
+// ListChatAccounts handles GET /chat-accounts and lists the Slack and Discord accounts linked to the current user
+func (cc *ChatCommandsController) ListChatAccounts(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	accounts, err := cc.commandService().ListAccounts(userID)
+	if err != nil {
+		log.Printf("Failed to list chat accounts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list chat accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// UnlinkChatAccount handles DELETE /chat-accounts/:provider/:accountId
+func (cc *ChatCommandsController) UnlinkChatAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	err := cc.commandService().UnlinkAccount(userID, c.Param("provider"), c.Param("accountId"))
+	if errors.Is(err, repositories.ErrChatAccountNotLinked) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat account not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to unlink chat account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink chat account"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
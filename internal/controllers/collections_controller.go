@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CollectionsController struct {
+	DB *pgxpool.Pool
+}
+
+func NewCollectionsController(db *pgxpool.Pool) *CollectionsController {
+	return &CollectionsController{DB: db}
+}
+
+// collectionService builds the collection service for a request
+func (cc *CollectionsController) collectionService() services.CollectionService {
+	return services.NewCollectionService(
+		repositories.NewCollectionRepository(cc.DB),
+		repositories.NewBookmarkRepository(cc.DB),
+		repositories.NewTagRepository(cc.DB),
+	)
+}
+
+// ListCollections handles GET /collections
+func (cc *CollectionsController) ListCollections(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collections, err := cc.collectionService().ListCollections(userID)
+	if err != nil {
+		log.Printf("Failed to list collections: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list collections"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// CreateCollection handles POST /collections
+func (cc *CollectionsController) CreateCollection(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	collection, err := cc.collectionService().CreateCollection(userID, input.Name, input.Description)
+	if err != nil {
+		log.Printf("Failed to create collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collection": collection})
+}
+
+// GetCollection handles GET /collections/:id and includes the collection's bookmarks in order
+func (cc *CollectionsController) GetCollection(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	page, limit := paginationParams(c)
+	collectionService := cc.collectionService()
+	collection, err := collectionService.GetCollection(userID, collectionID)
+	if err != nil {
+		respondCollectionError(c, err, "Failed to fetch collection")
+		return
+	}
+	collection.Bookmarks, err = collectionService.ListBookmarksWithTags(userID, collectionID, page, limit)
+	if err != nil {
+		respondCollectionError(c, err, "Failed to fetch collection")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collection": collection})
+}
+
+// UpdateCollection handles PATCH /collections/:id
+func (cc *CollectionsController) UpdateCollection(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	updateFields := make(map[string]interface{})
+	if input.Name != nil {
+		if *input.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Collection name must not be empty"})
+			return
+		}
+		updateFields["name"] = *input.Name
+	}
+	if input.Description != nil {
+		updateFields["description"] = *input.Description
+	}
+	collection, err := cc.collectionService().UpdateCollection(userID, collectionID, updateFields)
+	if err != nil {
+		respondCollectionError(c, err, "Failed to update collection")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collection": collection})
+}
+
+// DeleteCollection handles DELETE /collections/:id. Bookmarks in the collection are kept.
+func (cc *CollectionsController) DeleteCollection(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	if err := cc.collectionService().DeleteCollection(userID, collectionID); err != nil {
+		respondCollectionError(c, err, "Failed to delete collection")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddBookmark handles POST /collections/:id/bookmarks and appends a bookmark to the collection
+func (cc *CollectionsController) AddBookmark(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	var input struct {
+		BookmarkID int `json:"bookmark_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := cc.collectionService().AddBookmark(userID, collectionID, input.BookmarkID); err != nil {
+		respondCollectionError(c, err, "Failed to add bookmark to collection")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveBookmark handles DELETE /collections/:id/bookmarks/:bookmarkId
+func (cc *CollectionsController) RemoveBookmark(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	bookmarkID, err := strconv.Atoi(c.Param("bookmarkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+	if err := cc.collectionService().RemoveBookmark(userID, collectionID, bookmarkID); err != nil {
+		respondCollectionError(c, err, "Failed to remove bookmark from collection")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReorderBookmarks handles PUT /collections/:id/order with the bookmark IDs in their new order
+func (cc *CollectionsController) ReorderBookmarks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	var input struct {
+		BookmarkIDs []int `json:"bookmark_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := cc.collectionService().ReorderBookmarks(userID, collectionID, input.BookmarkIDs); err != nil {
+		respondCollectionError(c, err, "Failed to reorder collection")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondCollectionError maps collection service errors to HTTP responses
+func respondCollectionError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrCollectionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if errors.Is(err, services.ErrBookmarkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// paginationParams reads the page and limit query parameters with the usual defaults
+func paginationParams(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	return page, limit
+}
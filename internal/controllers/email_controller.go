@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EmailController struct {
+	DB *pgxpool.Pool
+}
+
+func NewEmailController(db *pgxpool.Pool) *EmailController {
+	return &EmailController{DB: db}
+}
+
+func (ec *EmailController) gateway() *services.EmailGatewayService {
+	bookmarkService := services.NewBookmarkServiceWithWebhooks(ec.DB)
+	return services.NewEmailGatewayService(bookmarkService, repositories.NewEmailRepository(ec.DB))
+}
+
+// InboundEmail handles POST /email/inbound with a raw RFC 5322 message as the body, for mail services
+// that forward inbound mail over HTTP. The request must carry EMAIL_GATEWAY_SECRET in the X-Email-Gateway-Secret header.
+func (ec *EmailController) InboundEmail(c *gin.Context) {
+	secret := os.Getenv("EMAIL_GATEWAY_SECRET")
+	token := c.GetHeader("X-Email-Gateway-Secret")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, services.EmailMaxMessageBytes)
+	result, err := ec.gateway().SaveEmail("", body)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Message too large"})
+	case errors.Is(err, services.ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email message"})
+	case errors.Is(err, services.ErrUnknownSender):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sender address is not registered"})
+	case err != nil:
+		log.Printf("Failed to save bookmarks from email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bookmarks"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// ListAddresses handles GET /email/addresses and lists the sender addresses of the current user
+func (ec *EmailController) ListAddresses(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	addresses, err := ec.gateway().ListAddresses(userID)
+	if err != nil {
+		log.Printf("Failed to list email addresses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list email addresses"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"addresses": addresses})
+}
+
+// AddAddress handles POST /email/addresses and registers an address the current user mails bookmarks from.
+// The address is mailed a confirmation code and accepts no mail until it is confirmed with POST /email/addresses/confirm.
+func (ec *EmailController) AddAddress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req struct {
+		Address string `json:"address" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	address, err := ec.gateway().AddAddress(userID, req.Address)
+	switch {
+	case errors.Is(err, services.ErrInvalidEmailAddress):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+	case errors.Is(err, repositories.ErrEmailAddressTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address is already registered"})
+	case errors.Is(err, services.ErrMailerDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Outgoing mail is not configured"})
+	case err != nil:
+		log.Printf("Failed to add email address: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add email address"})
+	default:
+		c.JSON(http.StatusAccepted, address)
+	}
+}
+
+// ConfirmAddress handles POST /email/addresses/confirm with the code mailed to an address the current user added
+func (ec *EmailController) ConfirmAddress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	address, err := ec.gateway().ConfirmAddress(userID, req.Code)
+	switch {
+	case errors.Is(err, repositories.ErrEmailConfirmationInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation code"})
+	case errors.Is(err, repositories.ErrEmailAddressTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address is already registered"})
+	case err != nil:
+		log.Printf("Failed to confirm email address: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm email address"})
+	default:
+		c.JSON(http.StatusOK, address)
+	}
+}
+
+// DeleteAddress handles DELETE /email/addresses/:id
+func (ec *EmailController) DeleteAddress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+	err = ec.gateway().DeleteAddress(userID, id)
+	if errors.Is(err, repositories.ErrEmailAddressNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email address not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete email address: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete email address"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExportController struct {
+	DB *pgxpool.Pool
+}
+
+func NewExportController(db *pgxpool.Pool) *ExportController {
+	return &ExportController{DB: db}
+}
+
+// ExportBookmarks handles GET /export?format=json|html[&collection=<id>]
+// and returns all bookmarks, or those of one collection, as a downloadable file.
+func (ec *ExportController) ExportBookmarks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format, use 'json' or 'html'"})
+		return
+	}
+
+	bookmarkRepo := repositories.NewBookmarkRepository(ec.DB)
+	tagRepo := repositories.NewTagRepository(ec.DB)
+	exportService := services.NewExportService(repositories.NewHighlightRepository(ec.DB))
+
+	title := "Bookmarks"
+	var fetch func(page int, pageSize int) ([]models.Bookmark, error)
+	if collectionParam := c.Query("collection"); collectionParam != "" {
+		collectionID, err := strconv.Atoi(collectionParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+			return
+		}
+		collectionService := services.NewCollectionService(repositories.NewCollectionRepository(ec.DB), bookmarkRepo, tagRepo)
+		collection, err := collectionService.GetCollection(userID, collectionID)
+		if err != nil {
+			respondCollectionError(c, err, "Failed to export collection")
+			return
+		}
+		title = collection.Name
+		fetch = func(page int, pageSize int) ([]models.Bookmark, error) {
+			return collectionService.ListBookmarksWithTags(userID, collectionID, page, pageSize)
+		}
+	} else {
+		bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
+		fetch = func(page int, pageSize int) ([]models.Bookmark, error) {
+			return bookmarkService.ListBookmarksWithTags(services.ViewerFilter(userID), page, pageSize)
+		}
+	}
+
+	bookmarks, err := exportService.CollectBookmarks(fetch)
+	if err != nil {
+		log.Printf("Failed to collect bookmarks for export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export bookmarks"})
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/json"
+	if format == "html" {
+		contentType = "text/html; charset=utf-8"
+		err = exportService.WriteNetscapeHTML(&buf, title, bookmarks)
+	} else {
+		err = exportService.WritePinboardJSON(&buf, bookmarks)
+	}
+	if err != nil {
+		log.Printf("Failed to write export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export bookmarks"})
+		return
+	}
+	filename := "bookmarks_" + time.Now().Format("2006-01-02") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxHookBodyBytes caps the body of inbound hook and slash command requests
+const maxHookBodyBytes = 1 << 20
+
+type HooksController struct {
+	DB *pgxpool.Pool
+}
+
+func NewHooksController(db *pgxpool.Pool) *HooksController {
+	return &HooksController{DB: db}
+}
+
+func (hc *HooksController) hookService() *services.InboundHookService {
+	bookmarkService := services.NewBookmarkServiceWithWebhooks(hc.DB)
+	return services.NewInboundHookService(bookmarkService, repositories.NewInboundHookRepository(hc.DB))
+}
+
+// ReceiveHook handles POST /hooks/:id, a request signed with the hook's secret (see services.InboundHookService)
+func (hc *HooksController) ReceiveHook(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxHookBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		return
+	}
+	bookmarks, err := hc.hookService().Receive(c.Param("id"), c.GetHeader("X-Bookmarker-Timestamp"), c.GetHeader("X-Bookmarker-Signature"), body)
+	switch {
+	case errors.Is(err, repositories.ErrInboundHookNotFound), errors.Is(err, services.ErrInvalidHookSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case errors.Is(err, services.ErrInvalidHookPayload):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	case errors.Is(err, services.ErrInvalidReadState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to save bookmarks from inbound hook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bookmarks"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks})
+	}
+}
+
+// ListHooks handles GET /hooks and lists the current user's hooks without their secrets
+func (hc *HooksController) ListHooks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	hooks, err := hc.hookService().ListHooks(userID)
+	if err != nil {
+		log.Printf("Failed to list inbound hooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list hooks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hooks": hooks})
+}
+
+// CreateHook handles POST /hooks. The response is the only time the hook's secret is shown.
+func (hc *HooksController) CreateHook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	hook, err := hc.hookService().CreateHook(userID, req.Name)
+	if err != nil {
+		log.Printf("Failed to create inbound hook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create hook"})
+		return
+	}
+	c.JSON(http.StatusCreated, hook)
+}
+
+// DeleteHook handles DELETE /hooks/:id
+func (hc *HooksController) DeleteHook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	err := hc.hookService().DeleteHook(userID, c.Param("id"))
+	if errors.Is(err, repositories.ErrInboundHookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hook not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete inbound hook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete hook"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
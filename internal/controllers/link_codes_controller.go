@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LinkCodesController struct {
+	DB *pgxpool.Pool
+}
+
+func NewLinkCodesController(db *pgxpool.Pool) *LinkCodesController {
+	return &LinkCodesController{DB: db}
+}
+
+// CreateLinkCode handles POST /link-codes with {"provider": "telegram" | "slack" | "discord"} and issues a one-time
+// code that links a chat or account of that provider to the current user
+func (lc *LinkCodesController) CreateLinkCode(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req struct {
+		Provider string `json:"provider" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	linkCodeService := services.NewLinkCodeService(repositories.NewLinkCodeRepository(lc.DB))
+	code, expiresAt, err := linkCodeService.CreateLinkCode(userID, req.Provider)
+	if errors.Is(err, services.ErrInvalidLinkProvider) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provider must be telegram, slack or discord"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create link code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link code"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"code":         code,
+		"provider":     req.Provider,
+		"expires_at":   expiresAt,
+		"instructions": services.LinkInstructions(req.Provider, code),
+	})
+}
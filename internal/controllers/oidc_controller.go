@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"bookmarker/internal/clients"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie holds the hash of the login state, tying the callback to the browser that started the login
+const oidcStateCookie = "oidc_state"
+
+// OIDCController handles single sign-on through an OpenID Connect provider
+type OIDCController struct {
+	OIDCService *services.OIDCService
+	AuthService *services.AuthService
+}
+
+func NewOIDCController(oidcService *services.OIDCService, authService *services.AuthService) *OIDCController {
+	return &OIDCController{OIDCService: oidcService, AuthService: authService}
+}
+
+// Login handles GET /auth/oidc/login and redirects the browser to the identity provider.
+// The optional redirect query parameter is a local path to return to after login.
+func (oc *OIDCController) Login(c *gin.Context) {
+	authURL, stateHash, err := oc.OIDCService.BeginLogin(c.DefaultQuery("redirect", "/"))
+	if errors.Is(err, services.ErrOIDCDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to start OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to reach the identity provider"})
+		return
+	}
+	setOIDCStateCookie(c, stateHash, int(services.OIDCLoginStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link handles GET /auth/oidc/link for a signed-in user and redirects the browser to the identity provider.
+// The provider account the user logs in with is linked to the current user, who may then log in with it.
+func (oc *OIDCController) Link(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	authURL, stateHash, err := oc.OIDCService.BeginLink(int64(userID), c.DefaultQuery("redirect", "/"))
+	if errors.Is(err, services.ErrOIDCDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to start OIDC link: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to reach the identity provider"})
+		return
+	}
+	setOIDCStateCookie(c, stateHash, int(services.OIDCLoginStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback handles GET /auth/oidc/callback. It issues the same access and refresh token cookies
+// as a password login and redirects back to the app.
+func (oc *OIDCController) Callback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider refused the login", "reason": providerErr, "description": c.Query("error_description")})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+	// A callback URL from someone else's login must not sign this browser in to their account
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(repositories.HashToken(state))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login state is invalid or expired; log in again"})
+		return
+	}
+	setOIDCStateCookie(c, "", -1)
+	user, redirectPath, err := oc.OIDCService.CompleteLogin(code, state)
+	switch {
+	case errors.Is(err, services.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrInvalidOIDCState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login state is invalid or expired; log in again"})
+		return
+	case errors.Is(err, clients.ErrInvalidIDToken):
+		log.Printf("Rejected OIDC ID token: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token"})
+		return
+	case errors.Is(err, services.ErrOIDCNoUsername), errors.Is(err, services.ErrOIDCUserNotProvisioned),
+		errors.Is(err, services.ErrOIDCLinkRequiresLogin):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrOIDCIdentityInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to complete OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login with the identity provider failed"})
+		return
+	}
+	result, err := oc.AuthService.StartExternalSession(user, sessionClient(c))
+	if errors.Is(err, services.ErrAccountDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to start session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+	setTokenCookie(c, "access_token", result.AccessToken, false)
+	setTokenCookie(c, "refresh_token", result.RefreshToken, true)
+	c.Redirect(http.StatusFound, oc.OIDCService.PostLoginURL(redirectPath))
+}
+
+// setOIDCStateCookie sets the login state cookie for the callback path; a negative maxAge deletes it.
+// SameSite=Lax still sends it on the provider's top-level redirect back to the callback.
+func setOIDCStateCookie(c *gin.Context, stateHash string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateHash,
+		Path:     "/auth/oidc",
+		MaxAge:   maxAge,
+		Secure:   os.Getenv("APP_ENV") != "dev",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -24,6 +24,12 @@ func (sc *SearchController) SearchBookmarks(c *gin.Context) {
 	tagRepo := repositories.NewTagRepository(sc.DB)
 	bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
 
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	searchQuery := c.DefaultQuery("q", "")
 	pageStr := c.DefaultQuery("page", "1")
 	pageSizeStr := c.DefaultQuery("limit", "50")
@@ -41,7 +47,7 @@ func (sc *SearchController) SearchBookmarks(c *gin.Context) {
 		return
 	}
 
-	bookmarks, err := bookmarkService.SearchBookmarks(searchQuery, page, limit)
+	bookmarks, err := bookmarkService.SearchBookmarks(searchQuery, services.ViewerFilter(userID), page, limit)
 	if err != nil {
 		log.Printf("Failed to search bookmarks: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search bookmarks"})
@@ -60,6 +66,12 @@ func (sc *SearchController) SearchBookmarks(c *gin.Context) {
 
 // GetBookmarksByTag fetches bookmarks by tag name with pagination
 func (sc *SearchController) GetBookmarksByTag(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	tagName := c.Query("tag")
 	if tagName == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing tag parameter"})
@@ -79,6 +91,18 @@ func (sc *SearchController) GetBookmarksByTag(c *gin.Context) {
 	tagRepo := repositories.NewTagRepository(sc.DB)
 	bookmarkRepo := repositories.NewBookmarkRepository(sc.DB)
 
+	// Apply the same normalization and aliases used when tags are saved
+	tagName = services.TagNormalizationRulesFromEnv().Normalize(tagName)
+	aliases, err := tagRepo.ResolveTagAliases([]string{tagName})
+	if err != nil {
+		log.Printf("Failed to resolve tag aliases: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks for tag"})
+		return
+	}
+	if canonical, ok := aliases[tagName]; ok {
+		tagName = canonical
+	}
+
 	// Find tag by name
 	tag, err := tagRepo.GetTagByName(tagName)
 	if err != nil  {
@@ -87,7 +111,7 @@ func (sc *SearchController) GetBookmarksByTag(c *gin.Context) {
 	}
 
 	offset := (page - 1) * limit
-	bookmarks, err := bookmarkRepo.ListBookmarksByTag(int(tag.ID), offset, limit)
+	bookmarks, err := bookmarkRepo.ListBookmarksByTag(int(tag.ID), services.ViewerFilter(userID), offset, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks for tag"})
 		return
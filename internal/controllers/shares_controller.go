@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SharesController struct {
+	DB *pgxpool.Pool
+}
+
+func NewSharesController(db *pgxpool.Pool) *SharesController {
+	return &SharesController{DB: db}
+}
+
+// sharedPageTemplate renders the read-only public page of a share link
+var sharedPageTemplate = template.Must(template.New("shared").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{.Link.Title}}</title>
+<link rel="alternate" type="application/feed+json" href="{{.FeedURL}}">
+</head>
+<body>
+<h1>{{.Link.Title}}</h1>
+<ul>
+{{range .Bookmarks}}<li>
+<a href="{{.URL}}" rel="noopener nofollow">{{.Title}}</a>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .Tags}}<small>{{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}</small>{{end}}
+</li>
+{{else}}<li>Nothing here yet.</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// shareService builds the share service for a request
+func (sc *SharesController) shareService() services.ShareService {
+	return services.NewShareService(
+		repositories.NewShareLinkRepository(sc.DB),
+		repositories.NewBookmarkRepository(sc.DB),
+		repositories.NewTagRepository(sc.DB),
+		repositories.NewCollectionRepository(sc.DB),
+	)
+}
+
+// ListShares handles GET /shares
+func (sc *SharesController) ListShares(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	links, err := sc.shareService().ListShares(userID)
+	if err != nil {
+		log.Printf("Failed to list shares: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list shares"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": links})
+}
+
+// CreateShare handles POST /shares and publishes a collection, or the user's public bookmarks matching a tag or saved query
+func (sc *SharesController) CreateShare(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var input struct {
+		Kind         string `json:"kind" binding:"required"`
+		CollectionID int    `json:"collection_id"`
+		Tag          string `json:"tag"`
+		Query        string `json:"query"`
+		Title        string `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	link, err := sc.shareService().CreateShare(userID, services.ShareRequest{
+		Kind:         input.Kind,
+		CollectionID: input.CollectionID,
+		Tag:          input.Tag,
+		Query:        input.Query,
+		Title:        input.Title,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrCollectionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"share": link})
+}
+
+// RevokeShare handles DELETE /shares/:id
+func (sc *SharesController) RevokeShare(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	shareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share ID"})
+		return
+	}
+	if err := sc.shareService().RevokeShare(userID, shareID); err != nil {
+		if errors.Is(err, services.ErrShareNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+			return
+		}
+		log.Printf("Failed to revoke share: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SharedPage handles GET /shared/:token and renders the public HTML page (no authentication)
+func (sc *SharesController) SharedPage(c *gin.Context) {
+	link, bookmarks, ok := sc.loadShared(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("X-Robots-Tag", "noindex")
+	err := sharedPageTemplate.Execute(c.Writer, gin.H{
+		"Link":      link,
+		"Bookmarks": bookmarks,
+		"FeedURL":   "/shared/" + c.Param("token") + "/feed.json",
+	})
+	if err != nil {
+		log.Printf("Failed to render shared page: %v", err)
+	}
+}
+
+// SharedFeed handles GET /shared/:token/feed.json and returns a JSON Feed (https://jsonfeed.org/version/1.1)
+func (sc *SharesController) SharedFeed(c *gin.Context) {
+	link, bookmarks, ok := sc.loadShared(c)
+	if !ok {
+		return
+	}
+	items := make([]gin.H, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		items = append(items, gin.H{
+			"id":             b.URL,
+			"url":            b.URL,
+			"title":          b.Title,
+			"content_text":   b.Description,
+			"tags":           b.Tags,
+			"date_published": b.CreatedAt,
+		})
+	}
+	c.Header("X-Robots-Tag", "noindex")
+	c.JSON(http.StatusOK, gin.H{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title":   link.Title,
+		"items":   items,
+	})
+}
+
+// loadShared resolves the share token of the request, writing a 404 if it is unknown or revoked
+func (sc *SharesController) loadShared(c *gin.Context) (models.ShareLink, []models.PublicBookmark, bool) {
+	page, limit := paginationParams(c)
+	link, bookmarks, err := sc.shareService().GetSharedBookmarks(c.Param("token"), page, limit)
+	if err != nil {
+		if !errors.Is(err, services.ErrShareNotFound) {
+			log.Printf("Failed to load shared bookmarks: %v", err)
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return models.ShareLink{}, nil, false
+	}
+	return link, bookmarks, true
+}
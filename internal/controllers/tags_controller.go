@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -30,9 +32,19 @@ func (tc *TagsController) ListAllTags(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"tags": tags})
 }
 
-// ListTags handles GET /tags and returns paginated tags
+// ListTags handles GET /tags and returns paginated tags, or the full tag hierarchy with ?tree=true
 func (tc *TagsController) ListTags(c *gin.Context) {
 	tagRepo := repositories.NewTagRepository(tc.DB)
+	if c.Query("tree") == "true" {
+		tree, err := tagRepo.ListTagTree()
+		if err != nil {
+			log.Printf("Failed to list tag tree: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tags"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tags": tree})
+		return
+	}
 	// Get pagination params
 	pageStr := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", "50")
@@ -52,3 +64,96 @@ func (tc *TagsController) ListTags(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"tags": tags})
 }
+
+// RenameTag handles PATCH /tags/:id (admins only). Descendants are renamed with the tag, and the webhooks of users whose
+// bookmarks use them are notified with tag.renamed.
+func (tc *TagsController) RenameTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	tag, err := services.NewTagService(tc.DB).RenameTag(id, input.Name)
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+	case errors.Is(err, services.ErrTagExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTagName), errors.Is(err, services.ErrTagMove):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to rename tag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename tag"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"tag": tag})
+	}
+}
+
+// ListTagAliases handles GET /tags/aliases and returns all alias mappings
+func (tc *TagsController) ListTagAliases(c *gin.Context) {
+	tagRepo := repositories.NewTagRepository(tc.DB)
+	aliases, err := tagRepo.ListTagAliases()
+	if err != nil {
+		log.Printf("Failed to list tag aliases: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tag aliases"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"aliases": aliases})
+}
+
+// CreateTagAlias handles POST /tags/aliases and maps an alias to a canonical tag, creating the tag if needed (admins only)
+func (tc *TagsController) CreateTagAlias(c *gin.Context) {
+	var input struct {
+		Alias string `json:"alias" binding:"required"`
+		Tag   string `json:"tag" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	rules := services.TagNormalizationRulesFromEnv()
+	alias := rules.Normalize(input.Alias)
+	tagName := rules.Normalize(input.Tag)
+	if alias == "" || tagName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alias and tag must not be empty after normalization"})
+		return
+	}
+	if alias == tagName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alias must differ from the tag name"})
+		return
+	}
+
+	tagRepo := repositories.NewTagRepository(tc.DB)
+	tags, err := tagRepo.GetAndCreateTagsIfMissing([]string{tagName})
+	if err != nil || len(tags) == 0 {
+		log.Printf("Failed to get or create tag for alias: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag alias"})
+		return
+	}
+	tagAlias, err := tagRepo.CreateTagAlias(alias, int(tags[0].ID))
+	if err != nil {
+		log.Printf("Failed to create tag alias: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag alias"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alias": tagAlias})
+}
+
+// DeleteTagAlias handles DELETE /tags/aliases/:alias (admins only)
+func (tc *TagsController) DeleteTagAlias(c *gin.Context) {
+	alias := services.TagNormalizationRulesFromEnv().Normalize(c.Param("alias"))
+	tagRepo := repositories.NewTagRepository(tc.DB)
+	if err := tagRepo.DeleteTagAlias(alias); err != nil {
+		log.Printf("Failed to delete tag alias: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag alias"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
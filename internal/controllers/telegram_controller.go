@@ -5,12 +5,13 @@ import (
 	"bookmarker/internal/models"
 	"bookmarker/internal/repositories"
 	"bookmarker/internal/services"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -38,156 +39,104 @@ func (tc *TelegramController) TelegramWebhookHandler(c *gin.Context) {
 		return
 	}
 
-	// Extract URL and tags from message
-	url, tags, _, found := extractURLAndTagsFromMessage(update)
-	if !found {
-		c.JSON(http.StatusOK, gin.H{"status": "no valid url detected"})
-		return
-	}
-
-	log.Printf("[TelegramWebhookHandler] URL detected: %q (tags: %v)", url, tags)
-	// Initialize repositories and service
-	bookmarkRepo := repositories.NewBookmarkRepository(tc.DB)
-	tagRepo := repositories.NewTagRepository(tc.DB)
-	bookmarkService := services.NewBookmarkServiceWithTags(bookmarkRepo, tagRepo)
-
-	// Create the bookmark (title, description, thumbnail left empty)
-	bookmark, err := bookmarkService.CreateBookmarkWithTags(url, "", "", "", tags, time.Now())
+	bot := NewTelegramBot(tc.DB)
+	result, err := bot.HandleUpdate(update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bookmark", "details": err.Error()})
+		log.Printf("[TelegramWebhookHandler] Failed to handle update %d: %v", update.UpdateID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle update"})
 		return
 	}
-
-	// Extract chat ID
-	chatID := extractChatID(update)
-	if chatID != 0 {
-		sendTelegramConfirmation(chatID, &bookmark)
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status":   "bookmark saved",
-		"bookmark": bookmark,
-	})
-}
-
-// validateTelegramToken checks the Telegram webhook token
-func validateTelegramToken(c *gin.Context) bool {
-	secretToken := os.Getenv("WEBHOOK_SECRET")
-	token := c.Query("token")
-	return token == secretToken && secretToken != ""
+	c.JSON(http.StatusOK, result)
 }
 
-// parseAndLogTelegramUpdate parses and logs the incoming Telegram update
-func parseAndLogTelegramUpdate(c *gin.Context) (map[string]interface{}, bool) {
-	var update map[string]interface{}
-	if err := c.ShouldBindJSON(&update); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Telegram update"})
-		return nil, false
-	}
-	payloadBytes, _ := json.MarshalIndent(update, "", "  ")
-	log.Printf("[TelegramWebhookHandler] Received payload: %s", string(payloadBytes))
-	return update, true
+// NewTelegramBot wires the bot the webhook and the poller share; edits it makes are recorded in the bookmark history
+func NewTelegramBot(db *pgxpool.Pool) *services.TelegramBot {
+	bookmarkService := services.NewBookmarkServiceWithWebhooks(db)
+	linkService := services.NewTelegramLinkService(repositories.NewTelegramRepository(db))
+	return services.NewTelegramBot(bookmarkService, linkService, clients.NewTelegramApiClient())
 }
 
-// extractURLAndTagsFromMessage extracts the URL and tags from the Telegram message
-func extractURLAndTagsFromMessage(update map[string]interface{}) (string, []string, string, bool) {
-	message, ok := update["message"].(map[string]interface{})
+// CreateLinkCode handles POST /telegram/link-code and issues a one-time code that links a chat to the
+// current user when sent to the bot as /link <code>. POST /link-codes issues codes for Slack and Discord.
+func (tc *TelegramController) CreateLinkCode(c *gin.Context) {
+	userID, ok := currentUserID(c)
 	if !ok {
-		return "", nil, "", false
-	}
-	text, ok := message["text"].(string)
-	if !ok || text == "" {
-		return "", nil, "", false
-	}
-	var url string
-	var tags []string
-	words := make([]string, 0)
-	for _, w := range splitBySpace(text) {
-		if w != "" {
-			words = append(words, w)
-		}
-	}
-	if len(words) > 0 {
-		url = words[0]
-		if len(words) > 1 {
-			tags = words[1:]
-		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
-	if url == "" || !isValidURL(url) {
-		log.Printf("[TelegramWebhookHandler] No valid URL detected in message: %q", text)
-		return "", nil, text, false
+	linkCodeService := services.NewLinkCodeService(repositories.NewLinkCodeRepository(tc.DB))
+	code, expiresAt, err := linkCodeService.CreateLinkCode(userID, models.ChatProviderTelegram)
+	if err != nil {
+		log.Printf("Failed to create Telegram link code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link code"})
+		return
 	}
-	return url, tags, text, true
+	c.JSON(http.StatusCreated, gin.H{
+		"code":         code,
+		"expires_at":   expiresAt,
+		"instructions": services.LinkInstructions(models.ChatProviderTelegram, code),
+	})
 }
 
-// extractChatID extracts the chat ID from the Telegram update
-func extractChatID(update map[string]interface{}) int64 {
-	message, ok := update["message"].(map[string]interface{})
+// ListChats handles GET /telegram/chats and lists the chats linked to the current user
+func (tc *TelegramController) ListChats(c *gin.Context) {
+	userID, ok := currentUserID(c)
 	if !ok {
-		return 0
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
-	if chat, ok := message["chat"].(map[string]interface{}); ok {
-		if id, ok := chat["id"].(float64); ok {
-			return int64(id)
-		}
+	linkService := services.NewTelegramLinkService(repositories.NewTelegramRepository(tc.DB))
+	chats, err := linkService.ListChats(userID)
+	if err != nil {
+		log.Printf("Failed to list Telegram chats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list chats"})
+		return
 	}
-	return 0
+	c.JSON(http.StatusOK, gin.H{"chats": chats})
 }
 
-// sendTelegramConfirmation sends a confirmation message to the user via Telegram
-func sendTelegramConfirmation(chatID int64, bookmark *models.Bookmark) {
-	telegramClient := clients.NewTelegramApiClient()
-	msg := "URL: " + bookmark.URL
-	if len(bookmark.Tags) > 0 {
-		tagNames := make([]string, len(bookmark.Tags))
-		for i, tag := range bookmark.Tags {
-			tagNames[i] = tag.Name
-		}
-		msg += "\nTags: " + joinStrings(tagNames, ", ")
+// UnlinkChat handles DELETE /telegram/chats/:chatId
+func (tc *TelegramController) UnlinkChat(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
-	if err := telegramClient.SendMessage(chatID, msg); err != nil {
-		log.Printf("[TelegramWebhookHandler] Failed to send Telegram message: %v", err)
+	chatID, err := strconv.ParseInt(c.Param("chatId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
+		return
 	}
-}
-
-// splitBySpace splits a string by spaces (helper for parsing)
-func splitBySpace(s string) []string {
-	result := []string{}
-	start := 0
-	for i, c := range s {
-		if c == ' ' || c == '\t' || c == '\n' {
-			if start < i {
-				result = append(result, s[start:i])
-			}
-			start = i + 1
-		}
+	linkService := services.NewTelegramLinkService(repositories.NewTelegramRepository(tc.DB))
+	err = linkService.UnlinkChat(userID, chatID)
+	if errors.Is(err, repositories.ErrChatNotLinked) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+		return
 	}
-	if start < len(s) {
-		result = append(result, s[start:])
+	if err != nil {
+		log.Printf("Failed to unlink Telegram chat: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink chat"})
+		return
 	}
-	return result
+	c.Status(http.StatusNoContent)
 }
 
-// isValidURL validates if a string is a valid URL with http or https scheme
-func isValidURL(str string) bool {
-	u, err := url.ParseRequestURI(str)
-	if err != nil {
-		return false
-	}
-	if u.Scheme != "http" && u.Scheme != "https" {
-		return false
-	}
-	return true
+// validateTelegramToken checks the secret Telegram sends in the X-Telegram-Bot-Api-Secret-Token header
+// against TELEGRAM_WEBHOOK_SECRET, the secret_token registered with setWebhook (see telegram-setup)
+func validateTelegramToken(c *gin.Context) bool {
+	secretToken := os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	token := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	return secretToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secretToken)) == 1
 }
 
-// joinStrings joins a slice of strings with a separator
-func joinStrings(elems []string, sep string) string {
-	if len(elems) == 0 {
-		return ""
-	}
-	result := elems[0]
-	for _, s := range elems[1:] {
-		result += sep + s
+// parseAndLogTelegramUpdate parses and logs the incoming Telegram update
+func parseAndLogTelegramUpdate(c *gin.Context) (models.TelegramUpdate, bool) {
+	var update models.TelegramUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Telegram update"})
+		return update, false
 	}
-	return result
+	payloadBytes, _ := json.MarshalIndent(update, "", "  ")
+	log.Printf("[TelegramWebhookHandler] Received payload: %s", string(payloadBytes))
+	return update, true
 }
\ No newline at end of file
@@ -2,7 +2,11 @@ package controllers
 
 import (
 	"bookmarker/internal/services"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,10 +35,29 @@ func (uc *UserController) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
-	result, err := uc.AuthService.Authenticate(req.Username, req.Password)
-	if err != nil || result == nil {
+	result, err := uc.AuthService.Authenticate(req.Username, req.Password, sessionClient(c))
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
+	case errors.Is(err, services.ErrAccountDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	case errors.As(err, &throttled):
+		retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts", "retry_after": retryAfter})
+		return
+	case err != nil:
+		log.Printf("Failed to log in: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+	if result.ChallengeToken != "" {
+		// The password was right; the client completes the login with POST /login/2fa
+		c.JSON(http.StatusOK, gin.H{"two_factor_required": true, "challenge_token": result.ChallengeToken})
+		return
 	}
 	// Set access and refresh tokens as HTTP-only cookies
 	setTokenCookie(c, "access_token", result.AccessToken, false)
@@ -42,6 +65,146 @@ func (uc *UserController) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, UserLoginResponse{AccessToken: result.AccessToken, RefreshToken: result.RefreshToken})
 }
 
+// TwoFactorLoginRequest completes a login with a TOTP or recovery code
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// TwoFactorLogin handles POST /login/2fa, the second step of a login for users with two-factor authentication
+func (uc *UserController) TwoFactorLogin(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	result, err := uc.AuthService.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, sessionClient(c))
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	case errors.Is(err, services.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login challenge is invalid or expired; log in again"})
+		return
+	case errors.Is(err, services.ErrAccountDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	case errors.As(err, &throttled):
+		retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts", "retry_after": retryAfter})
+		return
+	case err != nil:
+		log.Printf("Failed to complete two-factor login: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+	setTokenCookie(c, "access_token", result.AccessToken, false)
+	setTokenCookie(c, "refresh_token", result.RefreshToken, true)
+	c.JSON(http.StatusOK, UserLoginResponse{AccessToken: result.AccessToken, RefreshToken: result.RefreshToken})
+}
+
+// TwoFactorCodeRequest carries a TOTP or recovery code confirming a two-factor change
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// SetupTwoFactor handles POST /2fa/setup and starts TOTP enrollment.
+// The provisioning URI is meant to be shown as a QR code for the authenticator app.
+func (uc *UserController) SetupTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	secret, uri, err := uc.AuthService.BeginTOTPEnrollment(userID)
+	if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to start two-factor enrollment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor enrollment"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"secret": secret, "provisioning_uri": uri})
+}
+
+// EnableTwoFactor handles POST /2fa/enable and confirms enrollment with a code from the app.
+// The recovery codes are only ever shown in this response.
+func (uc *UserController) EnableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	codes, err := uc.AuthService.ConfirmTOTPEnrollment(userID, req.Code)
+	if respondTwoFactorError(c, err, "Failed to enable two-factor authentication") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor handles POST /2fa/disable
+func (uc *UserController) DisableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	err := uc.AuthService.DisableTOTP(userID, req.Code)
+	if respondTwoFactorError(c, err, "Failed to disable two-factor authentication") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes handles POST /2fa/recovery-codes and replaces all recovery codes
+func (uc *UserController) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	codes, err := uc.AuthService.RegenerateRecoveryCodes(userID, req.Code)
+	if respondTwoFactorError(c, err, "Failed to regenerate recovery codes") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// respondTwoFactorError writes the response for a two-factor management error and reports whether there was one
+func respondTwoFactorError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+	return true
+}
+
 // RefreshRequest and Refresh handler
 
 type RefreshRequest struct {
@@ -63,7 +226,13 @@ func (uc *UserController) Refresh(c *gin.Context) {
 		}
 	}
 
-	result, err := uc.AuthService.RefreshTokens(req.RefreshToken)
+	result, err := uc.AuthService.RefreshTokens(req.RefreshToken, sessionClient(c))
+	if errors.Is(err, services.ErrRefreshTokenReused) {
+		setTokenCookie(c, "access_token", "", false)
+		setTokenCookie(c, "refresh_token", "", true)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used; the session has been revoked"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
 		return
@@ -133,6 +302,125 @@ func (uc *UserController) Me(c *gin.Context) {
     })
 }
 
+// ListSessions handles GET /sessions and lists the user's active logins
+func (uc *UserController) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessions, err := uc.AuthService.ListSessions(userID, c.GetInt("sessionID"))
+	if err != nil {
+		log.Printf("Failed to list sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession handles DELETE /sessions/:id and logs that session out
+func (uc *UserController) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+	err = uc.AuthService.RevokeSession(userID, sessionID)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if sessionID == c.GetInt("sessionID") {
+		setTokenCookie(c, "access_token", "", false)
+		setTokenCookie(c, "refresh_token", "", true)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeAllSessions handles DELETE /sessions and logs the user out everywhere.
+// With ?keep_current=true the session making the request stays logged in.
+func (uc *UserController) RevokeAllSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	keepSessionID := 0
+	if c.Query("keep_current") == "true" {
+		keepSessionID = c.GetInt("sessionID")
+	}
+	revoked, err := uc.AuthService.RevokeAllSessions(userID, keepSessionID)
+	if err != nil {
+		log.Printf("Failed to revoke sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	if keepSessionID == 0 {
+		setTokenCookie(c, "access_token", "", false)
+		setTokenCookie(c, "refresh_token", "", true)
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// ChangePasswordRequest replaces the password of the current user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword handles POST /me/password. All other sessions of the user are logged out.
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	err := uc.AuthService.ChangePassword(userID, c.GetInt("sessionID"), req.CurrentPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return
+	case errors.Is(err, services.ErrPasswordTooShort):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to change password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+// sessionClient describes the device making the request, for recording on its session
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+}
+
+// currentUserID returns the ID of the authenticated user attached by AuthMiddleware
+func currentUserID(c *gin.Context) (int, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+	userID, ok := userIDVal.(int)
+	return userID, ok
+}
+
 func setTokenCookie(c *gin.Context, name, value string, isRefreshToken bool) {
 	var maxAge int
 	if isRefreshToken {
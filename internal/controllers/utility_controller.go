@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bookmarker/internal/services"
+	"crypto/subtle"
 	"net/http"
 	"os"
 
@@ -25,7 +26,7 @@ func (uc *UtilityController) BackupDBHandler(c *gin.Context) {
 		return
 	}
 	webhookSecret := os.Getenv("WEBHOOK_SECRET")
-	if req.Token == "" || req.Token != webhookSecret {
+	if webhookSecret == "" || subtle.ConstantTimeCompare([]byte(req.Token), []byte(webhookSecret)) != 1 {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"bookmarker/internal/repositories"
+	"bookmarker/internal/services"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhooksController struct {
+	DB *pgxpool.Pool
+}
+
+func NewWebhooksController(db *pgxpool.Pool) *WebhooksController {
+	return &WebhooksController{DB: db}
+}
+
+func (wc *WebhooksController) webhookService() *services.WebhookService {
+	return services.NewWebhookService(repositories.NewWebhookRepository(wc.DB))
+}
+
+// webhookError writes the response for errors shared by the webhook endpoints and reports whether err was one
+func webhookError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, repositories.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// ListWebhooks handles GET /webhooks and lists the current user's webhooks without their secrets
+func (wc *WebhooksController) ListWebhooks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	webhooks, err := wc.webhookService().ListWebhooks(userID)
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// CreateWebhook handles POST /webhooks. Leaving out events subscribes to all of them.
+// The response is the only time the signing secret is shown.
+func (wc *WebhooksController) CreateWebhook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var req struct {
+		URL    string   `json:"url" binding:"required"`
+		Events []string `json:"events"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	webhook, err := wc.webhookService().CreateWebhook(userID, req.URL, req.Events)
+	if webhookError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// UpdateWebhook handles PATCH /webhooks/:id and changes the URL, events or active flag
+func (wc *WebhooksController) UpdateWebhook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	var req struct {
+		URL    *string  `json:"url"`
+		Events []string `json:"events"`
+		Active *bool    `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	webhook, err := wc.webhookService().UpdateWebhook(userID, id, req.URL, req.Events, req.Active)
+	if webhookError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id; its deliveries are deleted with it
+func (wc *WebhooksController) DeleteWebhook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	err = wc.webhookService().DeleteWebhook(userID, id)
+	if webhookError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/:id/deliveries, the delivery log with payloads, attempts and the last response or error
+func (wc *WebhooksController) ListDeliveries(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	deliveries, err := wc.webhookService().ListDeliveries(userID, id)
+	if webhookError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to list webhook deliveries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RetryDelivery handles POST /webhooks/:id/deliveries/:deliveryId/retry and queues the delivery to be sent again
+func (wc *WebhooksController) RetryDelivery(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+	delivery, err := wc.webhookService().RetryDelivery(userID, id, deliveryID)
+	if webhookError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to retry webhook delivery: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry delivery"})
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
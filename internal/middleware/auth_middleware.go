@@ -8,7 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates the access token and attaches the user ID to the context
+// AuthMiddleware validates the access token and attaches the user and session IDs to the context
 func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
@@ -26,13 +26,17 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header or access_token cookie missing or invalid"})
 			return
 		}
-		userID, err := authService.ValidateAccessToken(token)
+		t, err := authService.ValidateAccessToken(token, services.SessionClient{
+			UserAgent: c.Request.UserAgent(),
+			IPAddress: c.ClientIP(),
+		})
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
-		// Attach userID to context
-		c.Set("userID", userID)
+		// Attach userID and sessionID to context
+		c.Set("userID", int(t.UserID))
+		c.Set("sessionID", int(t.SessionID))
 		c.Next()
 	}
 }
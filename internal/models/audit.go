@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Audit events
+const (
+	AuditLoginFailed    = "login_failed"
+	AuditLoginThrottled = "login_throttled"
+	AuditLoginLockout   = "login_lockout"
+)
+
+// AuditEntry records a security-relevant event. UserID is nil when the event names no existing user.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	Event     string    `json:"event"`
+	Username  string    `json:"username,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -2,14 +2,54 @@ package models
 
 import "time"
 
+// Bookmark visibility levels
+const (
+	// VisibilityPrivate bookmarks are only visible to their owner
+	VisibilityPrivate = "private"
+	// VisibilityInstance bookmarks are visible to every user of this deployment
+	VisibilityInstance = "instance"
+	// VisibilityPublic bookmarks may also appear on public share pages and feeds
+	VisibilityPublic = "public"
+)
+
+// IsValidVisibility reports whether v is one of the known visibility levels
+func IsValidVisibility(v string) bool {
+	return v == VisibilityPrivate || v == VisibilityInstance || v == VisibilityPublic
+}
+
+// Reading states for the read-later queue. Bookmarks without a reading state are plain references.
+const (
+	ReadStateUnread   = "unread"
+	ReadStateReading  = "reading"
+	ReadStateRead     = "read"
+	ReadStateArchived = "archived"
+)
+
+// IsValidReadState reports whether s is one of the known reading states
+func IsValidReadState(s string) bool {
+	return s == ReadStateUnread || s == ReadStateReading || s == ReadStateRead || s == ReadStateArchived
+}
+
 // Bookmark represents the bookmarks table in the database.
+// Bookmarks without a UserID predate ownership (or came from an unlinked source) and are visible to every user.
 type Bookmark struct {
-	ID          int64       `json:"id"`
-	Title       string      `json:"title"`
-	Description *string     `json:"description,omitempty"`
-	Thumbnail   *string     `json:"thumbnail,omitempty"`
-	URL         string      `json:"url"`
-	Tags        []BookmarkTag `json:"tags"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID          int64   `json:"id"`
+	UserID      *int64  `json:"user_id,omitempty"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	Thumbnail   *string `json:"thumbnail,omitempty"`
+	URL         string  `json:"url"`
+	Visibility  string  `json:"visibility"`
+	// Notes is the owner's Markdown annotation; it is never filled from the URL preview
+	Notes *string `json:"notes,omitempty"`
+	// ReadState is nil for bookmarks kept for reference rather than queued for reading
+	ReadState          *string       `json:"read_state,omitempty"`
+	ReadStateUpdatedAt *time.Time    `json:"read_state_updated_at,omitempty"`
+	ReadAt             *time.Time    `json:"read_at,omitempty"`
+	Tags               []BookmarkTag `json:"tags"`
+	Highlights         []Highlight   `json:"highlights,omitempty"`
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
+	// DeletedAt is set while the bookmark is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Providers a link code can link; Telegram chats are kept apart from Slack and Discord accounts
+const (
+	ChatProviderTelegram = "telegram"
+	ChatProviderSlack    = "slack"
+	ChatProviderDiscord  = "discord"
+)
+
+// ChatAccount is a Slack or Discord account linked to a user; slash commands from it act for that user
+type ChatAccount struct {
+	Provider  string    `json:"provider"`
+	AccountID string    `json:"account_id"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Collection represents a named, manually ordered group of bookmarks owned by a user.
+type Collection struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Name          string     `json:"name"`
+	Description   *string    `json:"description,omitempty"`
+	BookmarkCount int        `json:"bookmark_count"`
+	Bookmarks     []Bookmark `json:"bookmarks,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
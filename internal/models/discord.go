@@ -0,0 +1,45 @@
+package models
+
+// DiscordInteraction is the part of a Discord interaction the slash commands use.
+// Member is set for commands run in a server, User for commands run in a direct message.
+type DiscordInteraction struct {
+	Type   int                    `json:"type"`
+	Data   DiscordInteractionData `json:"data"`
+	Member *DiscordMember         `json:"member,omitempty"`
+	User   *DiscordUser           `json:"user,omitempty"`
+}
+
+const (
+	DiscordInteractionPing               = 1
+	DiscordInteractionApplicationCommand = 2
+)
+
+type DiscordInteractionData struct {
+	Name    string                 `json:"name"`
+	Options []DiscordCommandOption `json:"options,omitempty"`
+}
+
+// DiscordCommandOption is an option of a slash command; subcommands (type 1) and groups (type 2) nest further options
+type DiscordCommandOption struct {
+	Name    string                 `json:"name"`
+	Type    int                    `json:"type"`
+	Value   interface{}            `json:"value,omitempty"`
+	Options []DiscordCommandOption `json:"options,omitempty"`
+}
+
+type DiscordMember struct {
+	User DiscordUser `json:"user"`
+}
+
+type DiscordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// InvokingUser returns the user who ran the command
+func (i DiscordInteraction) InvokingUser() *DiscordUser {
+	if i.Member != nil {
+		return &i.Member.User
+	}
+	return i.User
+}
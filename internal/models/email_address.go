@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// EmailAddress is a sender address registered by a user; once confirmed, bookmarks mailed from it belong to that user
+type EmailAddress struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Address    string     `json:"address"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
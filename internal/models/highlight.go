@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Highlight is a passage quoted from a bookmarked page, with an optional note about it.
+type Highlight struct {
+	ID         int64     `json:"id"`
+	BookmarkID int64     `json:"bookmark_id"`
+	Quote      string    `json:"quote"`
+	Note       *string   `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
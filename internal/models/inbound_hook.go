@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// InboundHook is a signed webhook that saves bookmarks for its user. Secret is only returned when the hook is created.
+type InboundHook struct {
+	ID         string     `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Secret     string     `json:"secret,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Login throttle scopes
+const (
+	ThrottleScopeUsername = "username"
+	ThrottleScopeIP       = "ip"
+)
+
+// LoginThrottle counts recent failed logins for a username or an IP address
+type LoginThrottle struct {
+	Scope         string     `json:"scope"`
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	// LockedUntil is when the next attempt is allowed
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
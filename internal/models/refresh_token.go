@@ -3,11 +3,15 @@ package models
 import "time"
 
 // RefreshToken represents a refresh token for a user
+// Only the SHA-256 hash of the token is stored; the token itself is known only to the client.
 type RefreshToken struct {
-	ID           int64     `json:"id"`
-	UserID       int64     `json:"user_id"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID               int64     `json:"id"`
+	UserID           int64     `json:"user_id"`
+	SessionID        int64     `json:"session_id"`
+	RefreshTokenHash string    `json:"-"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	// UsedAt is set once the token has been exchanged; presenting it again means it was stolen
+	UsedAt *time.Time `json:"used_at,omitempty"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RevisionChange records one bookmark field going from one value to another; nil means the field was empty
+type RevisionChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+// TagChange records the tag names of a bookmark before and after an edit
+type TagChange struct {
+	From []string `json:"from"`
+	To   []string `json:"to"`
+}
+
+// BookmarkRevision is one recorded edit of a bookmark.
+// Tags is nil when the edit left the tags alone.
+type BookmarkRevision struct {
+	ID         int64            `json:"id"`
+	BookmarkID int64            `json:"bookmark_id"`
+	UserID     *int64           `json:"user_id,omitempty"`
+	Changes    []RevisionChange `json:"changes"`
+	Tags       *TagChange       `json:"tags,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
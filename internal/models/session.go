@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is one login on one device. Every access and refresh token belongs to a session,
+// and revoking the session revokes all of them.
+type Session struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
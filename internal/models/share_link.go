@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Share link kinds
+const (
+	ShareKindCollection = "collection"
+	ShareKindTag        = "tag"
+	ShareKindQuery      = "query"
+)
+
+// ShareLink publishes a collection, tag or saved search as a read-only page at an unguessable URL.
+type ShareLink struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	Token        string     `json:"token"`
+	Kind         string     `json:"kind"`
+	CollectionID *int64     `json:"collection_id,omitempty"`
+	TagID        *int64     `json:"tag_id,omitempty"`
+	Query        *string    `json:"query,omitempty"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// PublicBookmark is the subset of a bookmark that may be shown on public pages and feeds.
+type PublicBookmark struct {
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   time.Time `json:"created_at"`
+}
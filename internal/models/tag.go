@@ -3,9 +3,24 @@ package models
 import "time"
 
 // Tag represents the tags table in the database.
+// Hierarchical tags store their full path in Name (e.g. "lang/go") and
+// point at their parent ("lang") through ParentID.
 type Tag struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
+	ParentID  *int64    `json:"parent_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// TagNode is a tag together with its child tags, used for tree listings.
+type TagNode struct {
+	Tag
+	Children []TagNode `json:"children"`
+}
+
+// TagCount is a tag together with how many bookmarks carry it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
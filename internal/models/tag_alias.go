@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TagAlias maps an alternative tag name (e.g. "js") to its canonical tag (e.g. "javascript").
+type TagAlias struct {
+	ID        int64     `json:"id"`
+	Alias     string    `json:"alias"`
+	TagID     int64     `json:"tag_id"`
+	TagName   string    `json:"tag_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,117 @@
+package models
+
+// TelegramUpdate is an incoming update from the Telegram Bot API. At most one of the message fields is set.
+type TelegramUpdate struct {
+	UpdateID          int64            `json:"update_id"`
+	Message           *TelegramMessage `json:"message,omitempty"`
+	EditedMessage     *TelegramMessage `json:"edited_message,omitempty"`
+	ChannelPost       *TelegramMessage `json:"channel_post,omitempty"`
+	EditedChannelPost *TelegramMessage `json:"edited_channel_post,omitempty"`
+	// CallbackQuery is set when an inline keyboard button was pressed
+	CallbackQuery *TelegramCallbackQuery `json:"callback_query,omitempty"`
+}
+
+// EffectiveMessage returns the message the update carries, whether new, edited or posted in a channel
+func (u TelegramUpdate) EffectiveMessage() *TelegramMessage {
+	for _, message := range []*TelegramMessage{u.Message, u.EditedMessage, u.ChannelPost, u.EditedChannelPost} {
+		if message != nil {
+			return message
+		}
+	}
+	return nil
+}
+
+// IsEdit reports whether the update is an edit of a message seen before
+func (u TelegramUpdate) IsEdit() bool {
+	return u.EditedMessage != nil || u.EditedChannelPost != nil
+}
+
+// TelegramMessage is a message in a chat. Text and Entities are set for text messages,
+// Caption and CaptionEntities for media.
+type TelegramMessage struct {
+	MessageID       int64                   `json:"message_id"`
+	From            *TelegramUser           `json:"from,omitempty"`
+	Chat            TelegramChatInfo        `json:"chat"`
+	Date            int64                   `json:"date"`
+	Text            string                  `json:"text,omitempty"`
+	Entities        []TelegramMessageEntity `json:"entities,omitempty"`
+	Caption         string                  `json:"caption,omitempty"`
+	CaptionEntities []TelegramMessageEntity `json:"caption_entities,omitempty"`
+	ForwardOrigin   *TelegramMessageOrigin  `json:"forward_origin,omitempty"`
+	// ForwardFromChat and ForwardFromMessageID are the forward fields of Bot API versions before 7.0
+	ForwardFromChat      *TelegramChatInfo `json:"forward_from_chat,omitempty"`
+	ForwardFromMessageID int64             `json:"forward_from_message_id,omitempty"`
+	// ReplyToMessage is the message this one replies to
+	ReplyToMessage *TelegramMessage `json:"reply_to_message,omitempty"`
+}
+
+// TelegramMessageEntity marks a special span of a message such as a URL or hashtag.
+// Offset and Length count UTF-16 code units.
+type TelegramMessageEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	// URL is set for text_link entities
+	URL string `json:"url,omitempty"`
+}
+
+// TelegramMessageOrigin describes where a forwarded message came from
+type TelegramMessageOrigin struct {
+	Type      string            `json:"type"`
+	Chat      *TelegramChatInfo `json:"chat,omitempty"`
+	MessageID int64             `json:"message_id,omitempty"`
+}
+
+// TelegramUser is the sender of a message
+type TelegramUser struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username,omitempty"`
+}
+
+// TelegramChatInfo is the chat a message belongs to
+type TelegramChatInfo struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// TelegramCallbackQuery is sent when a user presses an inline keyboard button. Message is the message
+// the keyboard is attached to; Data is the button's callback data.
+type TelegramCallbackQuery struct {
+	ID      string           `json:"id"`
+	From    TelegramUser     `json:"from"`
+	Message *TelegramMessage `json:"message,omitempty"`
+	Data    string           `json:"data,omitempty"`
+}
+
+// TelegramInlineKeyboardMarkup is a keyboard of buttons shown under a message
+type TelegramInlineKeyboardMarkup struct {
+	InlineKeyboard [][]TelegramInlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// TelegramInlineKeyboardButton either sends CallbackData back to the bot or opens URL
+type TelegramInlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// TelegramForceReply asks the client to show a reply field for the message
+type TelegramForceReply struct {
+	ForceReply            bool   `json:"force_reply"`
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+}
+
+// TelegramWebhookInfo is the webhook status returned by getWebhookInfo
+type TelegramWebhookInfo struct {
+	URL                  string   `json:"url"`
+	PendingUpdateCount   int      `json:"pending_update_count"`
+	LastErrorDate        int64    `json:"last_error_date,omitempty"`
+	LastErrorMessage     string   `json:"last_error_message,omitempty"`
+	MaxConnections       int      `json:"max_connections,omitempty"`
+	AllowedUpdates       []string `json:"allowed_updates,omitempty"`
+	HasCustomCertificate bool     `json:"has_custom_certificate"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// TelegramChat is a Telegram chat linked to a user; bookmarks sent from it belong to that user
+type TelegramChat struct {
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
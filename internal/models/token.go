@@ -3,10 +3,12 @@ package models
 import "time"
 
 // Token represents an access token for a user
+// Only the SHA-256 hash of the token is stored; the token itself is known only to the client.
 type Token struct {
 	ID        int64     `json:"id"`
 	UserID    int64     `json:"user_id"`
-	Token     string    `json:"token"`
+	SessionID int64     `json:"session_id"`
+	TokenHash string    `json:"-"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	ExpiresAt time.Time `json:"expires_at"`
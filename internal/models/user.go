@@ -4,9 +4,18 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID           int64     `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	IsAdmin      bool   `json:"is_admin"`
+	// DisabledAt is set while the account may not log in
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	// TOTPSecret is set from the start of two-factor enrollment; TOTPEnabledAt once it is confirmed
+	TOTPSecret    *string    `json:"-"`
+	TOTPEnabledAt *time.Time `json:"-"`
+	// TOTPLastStep is the last time step a code was accepted for, so codes cannot be replayed
+	TOTPLastStep     int64     `json:"-"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
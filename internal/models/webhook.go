@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Events outgoing webhooks can subscribe to
+const (
+	WebhookEventBookmarkCreated = "bookmark.created"
+	WebhookEventBookmarkUpdated = "bookmark.updated"
+	WebhookEventBookmarkDeleted = "bookmark.deleted"
+	WebhookEventTagRenamed      = "tag.renamed"
+)
+
+// WebhookEvents lists every event, in the order they are documented
+var WebhookEvents = []string{WebhookEventBookmarkCreated, WebhookEventBookmarkUpdated, WebhookEventBookmarkDeleted, WebhookEventTagRenamed}
+
+// IsValidWebhookEvent reports whether event is one of the WebhookEvent* constants
+func IsValidWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of a webhook delivery
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an outgoing webhook of a user. Secret signs the payloads and is only returned when the webhook is created.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event queued for, or sent to, a webhook
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus *int       `json:"response_status"`
+	LastError      *string    `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	// URL and Secret are filled when a delivery is claimed for sending
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditRepository stores the audit log
+type AuditRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{DB: db}
+}
+
+func (r *AuditRepository) CreateEntry(entry models.AuditEntry) error {
+	entry.CreatedAt = time.Now().UTC()
+	_, err := r.DB.Exec(context.Background(),
+		`INSERT INTO audit_log (user_id, event, username, ip_address, user_agent, detail, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.UserID, entry.Event, entry.Username, entry.IPAddress, entry.UserAgent, entry.Detail, entry.CreatedAt,
+	)
+	return err
+}
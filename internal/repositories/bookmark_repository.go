@@ -4,121 +4,179 @@ import (
 	"bookmarker/internal/models"
 	"context"
 	"strconv"
+	"strings"
 	"time"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// BookmarkFilter restricts which bookmarks a listing query may return.
+// The zero value returns every bookmark that is not in the trash.
+type BookmarkFilter struct {
+	// ViewerID limits results to bookmarks the user owns
+	ViewerID int
+	// IncludeShared widens ViewerID to other users' bookmarks shared with the instance or public
+	IncludeShared bool
+	// OwnerID limits results to bookmarks owned by this user
+	OwnerID int
+	// ExcludeOwnerID leaves out bookmarks owned by this user
+	ExcludeOwnerID int
+	// Visibilities limits results to the given visibility levels
+	Visibilities []string
+	// ReadStates limits results to the given reading states
+	ReadStates []string
+	// URL limits results to bookmarks of exactly this URL
+	URL string
+	// Trashed returns bookmarks in the trash instead of live ones
+	Trashed bool
+}
+
+// conditions returns the SQL conditions for the filter on the bookmarks alias "b",
+// appending their arguments to args so placeholders keep counting from the existing ones.
+func (f BookmarkFilter) conditions(args []interface{}) ([]string, []interface{}) {
+	conds := []string{"b.deleted_at IS NULL"}
+	if f.Trashed {
+		conds[0] = "b.deleted_at IS NOT NULL"
+	}
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	if f.ViewerID != 0 {
+		visible := "b.user_id = " + next(f.ViewerID)
+		if f.IncludeShared {
+			visible += " OR b.visibility IN ('" + models.VisibilityInstance + "', '" + models.VisibilityPublic + "')"
+		}
+		conds = append(conds, "("+visible+")")
+	}
+	if f.OwnerID != 0 {
+		conds = append(conds, "b.user_id = "+next(f.OwnerID))
+	}
+	if f.ExcludeOwnerID != 0 {
+		conds = append(conds, "b.user_id IS DISTINCT FROM "+next(f.ExcludeOwnerID))
+	}
+	if len(f.Visibilities) > 0 {
+		conds = append(conds, "b.visibility = ANY("+next(f.Visibilities)+")")
+	}
+	if len(f.ReadStates) > 0 {
+		conds = append(conds, "b.read_state = ANY("+next(f.ReadStates)+")")
+	}
+	if f.URL != "" {
+		conds = append(conds, "b.url = "+next(f.URL))
+	}
+	return conds, args
+}
+
+// whereClause joins the base conditions with the filter conditions into a WHERE clause
+func (f BookmarkFilter) whereClause(base []string, args []interface{}) (string, []interface{}) {
+	conds, args := f.conditions(args)
+	conds = append(base, conds...)
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
 // BookmarkRepository defines the interface for handling bookmarks with pagination support.
 type BookmarkRepository interface {
-	CreateBookmark(url, title, description, thumbnail string, createdAt time.Time) (models.Bookmark, error)
+	CreateBookmark(bookmark models.Bookmark) (models.Bookmark, error)
 	GetBookmarkByID(id int) (models.Bookmark, error)
-	ListBookmarks(offset int, limit int) ([]models.Bookmark, error)
-	ListBookmarksByTag(tagID int, offset int, limit int) ([]models.Bookmark, error)
+	ListBookmarks(filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error)
+	ListBookmarksByTag(tagID int, filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error)
 	UpdateBookmark(id int, fields map[string]interface{}) (models.Bookmark, error)
-	// SearchBookmarks performs a paginated text search on title, url, or description
-	SearchBookmarks(query string, offset int, limit int) ([]models.Bookmark, error)
-	DeleteBookmark(id int) error // Add this method to the interface
+	// SearchBookmarks performs a paginated text search on title, url, description, notes and highlights
+	SearchBookmarks(query string, filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error)
+	// DeleteBookmark moves a bookmark to the trash; its tags and collection memberships are kept
+	DeleteBookmark(id int) error
+	// GetTrashedBookmarkByID retrieves a bookmark that is in the trash
+	GetTrashedBookmarkByID(id int) (models.Bookmark, error)
+	RestoreBookmark(id int) (models.Bookmark, error)
+	// PurgeTrashedBefore permanently deletes bookmarks trashed before cutoff and returns how many were removed
+	PurgeTrashedBefore(cutoff time.Time) (int64, error)
+	// SetReadState changes the reading state of a bookmark; an empty state removes it from the queue
+	SetReadState(id int, state string) (models.Bookmark, error)
+	// GetNextUnread returns the oldest unread bookmark matching the filter
+	GetNextUnread(filter BookmarkFilter) (models.Bookmark, error)
+	// AssignUnownedBookmarks gives every bookmark without an owner to the user and returns how many were assigned
+	AssignUnownedBookmarks(userID int) (int64, error)
 }
 
 type bookmarkRepository struct {
-	db *pgxpool.Pool
+	db DBTX
 }
 
+// bookmarkColumns lists the columns scanned by scanBookmark, on the bookmarks alias "b"
+const bookmarkColumns = `b.id, b.user_id, b.title, b.description, b.thumbnail, b.url, b.visibility, b.notes,
+	b.read_state, b.read_state_updated_at, b.read_at, b.created_at, b.updated_at, b.deleted_at`
+
 // CreateBookmark adds a new bookmark to the database.
-func (r bookmarkRepository) CreateBookmark(url, title, description, thumbnail string, createdAt time.Time) (models.Bookmark, error) {
-	var bookmarkID int64
+func (r bookmarkRepository) CreateBookmark(bookmark models.Bookmark) (models.Bookmark, error) {
+	if bookmark.Visibility == "" {
+		bookmark.Visibility = models.VisibilityPrivate
+	}
+	bookmark.UpdatedAt = bookmark.CreatedAt
+	if bookmark.ReadState != nil {
+		bookmark.ReadStateUpdatedAt = &bookmark.CreatedAt
+	}
 	err := r.db.QueryRow(context.Background(),
-		`INSERT INTO bookmarks (url, title, description, thumbnail, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
-		url, title, description, thumbnail, createdAt, createdAt,
-	).Scan(&bookmarkID)
+		`INSERT INTO bookmarks (user_id, url, title, description, thumbnail, visibility, notes, read_state, read_state_updated_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		bookmark.UserID, bookmark.URL, bookmark.Title, bookmark.Description, bookmark.Thumbnail,
+		bookmark.Visibility, bookmark.Notes, bookmark.ReadState, bookmark.ReadStateUpdatedAt, bookmark.CreatedAt, bookmark.UpdatedAt,
+	).Scan(&bookmark.ID)
 	if err != nil {
 		return models.Bookmark{}, err
 	}
-	return models.Bookmark{
-		ID:          bookmarkID,
-		URL:         url,
-		Title:       title,
-		Description: &description,
-		Thumbnail:   &thumbnail,
-		CreatedAt:   createdAt,
-		UpdatedAt:   createdAt,
-	}, nil
+	return bookmark, nil
 }
 
-// GetBookmarkByID retrieves a bookmark by its ID.
+// GetBookmarkByID retrieves a bookmark by its ID. Bookmarks in the trash are not found.
 func (r bookmarkRepository) GetBookmarkByID(id int) (models.Bookmark, error) {
 	query := `
-		SELECT id, title, description, thumbnail, url, created_at, updated_at
-		FROM bookmarks
-		WHERE id = $1
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks b
+		WHERE b.id = $1 AND b.deleted_at IS NULL
 	`
-	row := r.db.QueryRow(context.Background(), query, id)
-	var bookmark models.Bookmark
-	err := row.Scan(&bookmark.ID, &bookmark.Title, &bookmark.Description, &bookmark.Thumbnail, &bookmark.URL, &bookmark.CreatedAt, &bookmark.UpdatedAt)
-	if err != nil {
-		return models.Bookmark{}, err
-	}
-	return bookmark, nil
+	return scanBookmark(r.db.QueryRow(context.Background(), query, id))
 }
 
-// ListBookmarks retrieves a paginated list of bookmarks.
-func (r bookmarkRepository) ListBookmarks(offset int, limit int) ([]models.Bookmark, error) {
+// GetTrashedBookmarkByID retrieves a bookmark that is in the trash.
+func (r bookmarkRepository) GetTrashedBookmarkByID(id int) (models.Bookmark, error) {
 	query := `
-		SELECT id, title, description, thumbnail, url, created_at, updated_at
-		FROM bookmarks
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks b
+		WHERE b.id = $1 AND b.deleted_at IS NOT NULL
 	`
-	rows, err := r.db.Query(context.Background(), query, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var bookmarks []models.Bookmark
-	for rows.Next() {
-		var bookmark models.Bookmark
-		err := rows.Scan(&bookmark.ID, &bookmark.Title, &bookmark.Description, &bookmark.Thumbnail, &bookmark.URL, &bookmark.CreatedAt, &bookmark.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		bookmarks = append(bookmarks, bookmark)
-	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
-	}
-	return bookmarks, nil
+	return scanBookmark(r.db.QueryRow(context.Background(), query, id))
+}
+
+// ListBookmarks retrieves a paginated list of bookmarks.
+func (r bookmarkRepository) ListBookmarks(filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error) {
+	where, args := filter.whereClause(nil, nil)
+	args = append(args, limit, offset)
+	query := `
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks b
+		` + where + `
+		ORDER BY b.created_at DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+	return r.queryBookmarks(query, args...)
 }
 
 // ListBookmarksByTag retrieves a paginated list of bookmarks filtered by a tag.
-func (r bookmarkRepository) ListBookmarksByTag(tagID int, offset int, limit int) ([]models.Bookmark, error) {
+// Bookmarks tagged with any descendant of the tag (e.g. "lang/go" for "lang") are included.
+func (r bookmarkRepository) ListBookmarksByTag(tagID int, filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error) {
+	where, args := filter.whereClause([]string{
+		"b.id IN (SELECT bt.bookmark_id FROM bookmarks_tags bt WHERE bt.tag_id IN (SELECT id FROM subtree))",
+	}, []interface{}{tagID})
+	args = append(args, limit, offset)
 	query := `
-		SELECT b.id, b.title, b.description, b.thumbnail, b.url, b.created_at, b.updated_at
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM tags WHERE id = $1
+			UNION
+			SELECT t.id FROM tags t INNER JOIN subtree s ON t.parent_id = s.id
+		)
+		SELECT ` + bookmarkColumns + `
 		FROM bookmarks b
-		INNER JOIN bookmarks_tags bt ON b.id = bt.bookmark_id
-		WHERE bt.tag_id = $1
+		` + where + `
 		ORDER BY b.created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-	rows, err := r.db.Query(context.Background(), query, tagID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var bookmarks []models.Bookmark
-	for rows.Next() {
-		var bookmark models.Bookmark
-		err := rows.Scan(&bookmark.ID, &bookmark.Title, &bookmark.Description, &bookmark.Thumbnail, &bookmark.URL, &bookmark.CreatedAt, &bookmark.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		bookmarks = append(bookmarks, bookmark)
-	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
-	}
-	return bookmarks, nil
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+	return r.queryBookmarks(query, args...)
 }
 
 // PatchBookmark updates only the provided fields and sets updated_at to now.
@@ -138,7 +196,7 @@ func (r bookmarkRepository) UpdateBookmark(id int, fields map[string]interface{}
 		i++
 	}
 	updatedAt := time.Now().UTC()
-	query += ", updated_at = $" + strconv.Itoa(i) + " WHERE id = $" + strconv.Itoa(i+1)
+	query += ", updated_at = $" + strconv.Itoa(i) + " WHERE id = $" + strconv.Itoa(i+1) + " AND deleted_at IS NULL"
 	args = append(args, updatedAt, id)
 	_, err := r.db.Exec(context.Background(), query, args...)
 	if err != nil {
@@ -147,24 +205,68 @@ func (r bookmarkRepository) UpdateBookmark(id int, fields map[string]interface{}
 	return r.GetBookmarkByID(id)
 }
 
-// SearchBookmarks performs a paginated text search on title, url, or description
-func (r bookmarkRepository) SearchBookmarks(query string, offset int, limit int) ([]models.Bookmark, error) {
+// SearchBookmarks performs a paginated text search on title, url, description, notes and highlights
+func (r bookmarkRepository) SearchBookmarks(query string, filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error) {
 	likeQuery := "%" + query + "%"
+	where, args := filter.whereClause([]string{
+		`(b.title ILIKE $1 OR b.url ILIKE $1 OR b.description ILIKE $1 OR b.notes ILIKE $1
+		  OR EXISTS (SELECT 1 FROM bookmark_highlights h WHERE h.bookmark_id = b.id AND (h.quote ILIKE $1 OR h.note ILIKE $1)))`,
+	}, []interface{}{likeQuery})
+	args = append(args, limit, offset)
 	sqlQuery := `
-		SELECT id, title, description, thumbnail, url, created_at, updated_at
-		FROM bookmarks
-		WHERE title ILIKE $1 OR url ILIKE $2 OR description ILIKE $3
-		LIMIT $4 OFFSET $5
-	`
-	rows, err := r.db.Query(context.Background(), sqlQuery, likeQuery, likeQuery, likeQuery, limit, offset)
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks b
+		` + where + `
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+	return r.queryBookmarks(sqlQuery, args...)
+}
+
+// SetReadState changes the reading state of a bookmark and records when it changed.
+// read_at is set the first time a bookmark is marked read; an empty state removes the bookmark from the queue.
+func (r bookmarkRepository) SetReadState(id int, state string) (models.Bookmark, error) {
+	now := time.Now().UTC()
+	var readState *string
+	if state != "" {
+		readState = &state
+	}
+	_, err := r.db.Exec(context.Background(),
+		`UPDATE bookmarks
+		 SET read_state = $1,
+		     read_state_updated_at = $2,
+		     read_at = CASE WHEN $1 = '`+models.ReadStateRead+`' THEN COALESCE(read_at, $2) ELSE read_at END,
+		     updated_at = $2
+		 WHERE id = $3 AND deleted_at IS NULL`,
+		readState, now, id,
+	)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
+	return r.GetBookmarkByID(id)
+}
+
+// GetNextUnread returns the oldest unread bookmark matching the filter
+func (r bookmarkRepository) GetNextUnread(filter BookmarkFilter) (models.Bookmark, error) {
+	filter.ReadStates = []string{models.ReadStateUnread}
+	where, args := filter.whereClause(nil, nil)
+	query := `
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks b
+		` + where + `
+		ORDER BY b.created_at ASC
+		LIMIT 1`
+	return scanBookmark(r.db.QueryRow(context.Background(), query, args...))
+}
+
+// queryBookmarks runs a query selecting bookmarkColumns and scans every row
+func (r bookmarkRepository) queryBookmarks(query string, args ...interface{}) ([]models.Bookmark, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var bookmarks []models.Bookmark
 	for rows.Next() {
-		var bookmark models.Bookmark
-		err := rows.Scan(&bookmark.ID, &bookmark.Title, &bookmark.Description, &bookmark.Thumbnail, &bookmark.URL, &bookmark.CreatedAt, &bookmark.UpdatedAt)
+		bookmark, err := scanBookmark(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -176,19 +278,66 @@ func (r bookmarkRepository) SearchBookmarks(query string, offset int, limit int)
 	return bookmarks, nil
 }
 
+// scanBookmark scans a row selected with bookmarkColumns
+func scanBookmark(row rowScanner) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	err := row.Scan(&bookmark.ID, &bookmark.UserID, &bookmark.Title, &bookmark.Description, &bookmark.Thumbnail,
+		&bookmark.URL, &bookmark.Visibility, &bookmark.Notes, &bookmark.ReadState, &bookmark.ReadStateUpdatedAt, &bookmark.ReadAt,
+		&bookmark.CreatedAt, &bookmark.UpdatedAt, &bookmark.DeletedAt)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
+	return bookmark, nil
+}
+
 // NewBookmarkRepository creates a new instance of bookmarkRepository.
-func NewBookmarkRepository(db *pgxpool.Pool) BookmarkRepository {
+func NewBookmarkRepository(db DBTX) BookmarkRepository {
 	return &bookmarkRepository{db: db}
 }
 
-// Implement DeleteBookmark for bookmarkRepository
+// DeleteBookmark moves a bookmark to the trash. Its tag associations and collection memberships
+// are kept so that a restore brings it back unchanged.
 func (r bookmarkRepository) DeleteBookmark(id int) error {
-	// First, delete all tag relationships for this bookmark
-	_, err := r.db.Exec(context.Background(), "DELETE FROM bookmarks_tags WHERE bookmark_id = $1", id)
+	_, err := r.db.Exec(context.Background(),
+		"UPDATE bookmarks SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", time.Now().UTC(), id)
+	return err
+}
+
+// RestoreBookmark takes a bookmark out of the trash.
+func (r bookmarkRepository) RestoreBookmark(id int) (models.Bookmark, error) {
+	_, err := r.db.Exec(context.Background(), "UPDATE bookmarks SET deleted_at = NULL WHERE id = $1", id)
 	if err != nil {
-		return err
+		return models.Bookmark{}, err
 	}
-	// Then, delete the bookmark itself
-	_, err = r.db.Exec(context.Background(), "DELETE FROM bookmarks WHERE id = $1", id)
-	return err
+	return r.GetBookmarkByID(id)
+}
+
+// AssignUnownedBookmarks gives every bookmark without an owner to the user.
+func (r bookmarkRepository) AssignUnownedBookmarks(userID int) (int64, error) {
+	tag, err := r.db.Exec(context.Background(),
+		`UPDATE bookmarks SET user_id = $1 WHERE user_id IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PurgeTrashedBefore permanently deletes bookmarks trashed before cutoff, together with their tag relationships.
+func (r bookmarkRepository) PurgeTrashedBefore(cutoff time.Time) (int64, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx,
+		`DELETE FROM bookmarks_tags WHERE bookmark_id IN (SELECT id FROM bookmarks WHERE deleted_at < $1)`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, "DELETE FROM bookmarks WHERE deleted_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), tx.Commit(ctx)
 }
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrChatAccountNotLinked is returned for Slack and Discord accounts not linked to an active user.
+var ErrChatAccountNotLinked = errors.New("chat account not linked")
+
+// ChatAccountRepository handles Slack and Discord accounts linked to users
+type ChatAccountRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewChatAccountRepository(db *pgxpool.Pool) *ChatAccountRepository {
+	return &ChatAccountRepository{DB: db}
+}
+
+// LinkAccount uses up a link code issued for the provider and links the account to the code's user, replacing an earlier link of the account
+func (r *ChatAccountRepository) LinkAccount(codeHash string, provider string, accountID string) (models.ChatAccount, error) {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return models.ChatAccount{}, err
+	}
+	defer tx.Rollback(ctx)
+	userID, err := consumeLinkCode(ctx, tx, provider, codeHash)
+	if err != nil {
+		return models.ChatAccount{}, err
+	}
+	account := models.ChatAccount{Provider: provider, AccountID: accountID, UserID: userID, CreatedAt: time.Now().UTC()}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO chat_accounts (provider, account_id, user_id, created_at) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (provider, account_id) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at`,
+		account.Provider, account.AccountID, account.UserID, account.CreatedAt)
+	if err != nil {
+		return models.ChatAccount{}, err
+	}
+	return account, tx.Commit(ctx)
+}
+
+// GetAccountUserID returns the user an account is linked to; accounts of disabled users count as unlinked
+func (r *ChatAccountRepository) GetAccountUserID(provider string, accountID string) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT a.user_id FROM chat_accounts a JOIN users u ON u.id = a.user_id
+		 WHERE a.provider = $1 AND a.account_id = $2 AND u.disabled_at IS NULL`, provider, accountID,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrChatAccountNotLinked
+	}
+	return userID, err
+}
+
+func (r *ChatAccountRepository) ListAccounts(userID int) ([]models.ChatAccount, error) {
+	rows, err := r.DB.Query(context.Background(),
+		`SELECT provider, account_id, user_id, created_at FROM chat_accounts WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var accounts []models.ChatAccount
+	for rows.Next() {
+		var account models.ChatAccount
+		if err := rows.Scan(&account.Provider, &account.AccountID, &account.UserID, &account.CreatedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return accounts, nil
+}
+
+// UnlinkAccount removes the link of one of the user's accounts
+func (r *ChatAccountRepository) UnlinkAccount(userID int, provider string, accountID string) error {
+	tag, err := r.DB.Exec(context.Background(),
+		`DELETE FROM chat_accounts WHERE provider = $1 AND account_id = $2 AND user_id = $3`, provider, accountID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrChatAccountNotLinked
+	}
+	return nil
+}
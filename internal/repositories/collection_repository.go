@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"strconv"
+	"time"
+)
+
+// CollectionRepository defines the interface for handling collections and their ordered bookmarks.
+type CollectionRepository interface {
+	CreateCollection(userID int, name, description string) (models.Collection, error)
+	GetCollectionByID(id int) (models.Collection, error)
+	ListCollections(userID int) ([]models.Collection, error)
+	UpdateCollection(id int, fields map[string]interface{}) (models.Collection, error)
+	DeleteCollection(id int) error
+	AddBookmarkToCollection(collectionID int, bookmarkID int) error
+	RemoveBookmarkFromCollection(collectionID int, bookmarkID int) error
+	// RemoveBookmarkFromOtherCollections removes a bookmark from every collection of the user except keepCollectionID
+	RemoveBookmarkFromOtherCollections(userID int, bookmarkID int, keepCollectionID int) error
+	// ReorderCollection sets the position of each bookmark to its index in bookmarkIDs
+	ReorderCollection(collectionID int, bookmarkIDs []int) error
+	ListBookmarksInCollection(collectionID int, filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error)
+}
+
+type collectionRepository struct {
+	db DBTX
+}
+
+const collectionColumns = `
+	c.id, c.user_id, c.name, c.description, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM collections_bookmarks cb
+	 INNER JOIN bookmarks b ON b.id = cb.bookmark_id
+	 WHERE cb.collection_id = c.id AND b.deleted_at IS NULL)
+`
+
+// CreateCollection adds a new collection to the database.
+func (r collectionRepository) CreateCollection(userID int, name, description string) (models.Collection, error) {
+	createdAt := time.Now().UTC()
+	var id int64
+	err := r.db.QueryRow(context.Background(),
+		`INSERT INTO collections (user_id, name, description, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		userID, name, description, createdAt, createdAt,
+	).Scan(&id)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	return models.Collection{
+		ID:          id,
+		UserID:      int64(userID),
+		Name:        name,
+		Description: &description,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}, nil
+}
+
+// GetCollectionByID retrieves a collection by its ID.
+func (r collectionRepository) GetCollectionByID(id int) (models.Collection, error) {
+	query := `SELECT ` + collectionColumns + ` FROM collections c WHERE c.id = $1`
+	var collection models.Collection
+	err := r.db.QueryRow(context.Background(), query, id).Scan(
+		&collection.ID, &collection.UserID, &collection.Name, &collection.Description,
+		&collection.CreatedAt, &collection.UpdatedAt, &collection.BookmarkCount,
+	)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	return collection, nil
+}
+
+// ListCollections retrieves all collections owned by a user.
+func (r collectionRepository) ListCollections(userID int) ([]models.Collection, error) {
+	query := `SELECT ` + collectionColumns + ` FROM collections c WHERE c.user_id = $1 ORDER BY c.name`
+	rows, err := r.db.Query(context.Background(), query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var collections []models.Collection
+	for rows.Next() {
+		var collection models.Collection
+		err := rows.Scan(
+			&collection.ID, &collection.UserID, &collection.Name, &collection.Description,
+			&collection.CreatedAt, &collection.UpdatedAt, &collection.BookmarkCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return collections, nil
+}
+
+// UpdateCollection updates only the provided fields and sets updated_at to now.
+func (r collectionRepository) UpdateCollection(id int, fields map[string]interface{}) (models.Collection, error) {
+	if len(fields) == 0 {
+		return r.GetCollectionByID(id)
+	}
+	query := "UPDATE collections SET "
+	args := []interface{}{}
+	i := 1
+	for k, v := range fields {
+		if i > 1 {
+			query += ", "
+		}
+		query += k + " = $" + strconv.Itoa(i)
+		args = append(args, v)
+		i++
+	}
+	query += ", updated_at = $" + strconv.Itoa(i) + " WHERE id = $" + strconv.Itoa(i+1)
+	args = append(args, time.Now().UTC(), id)
+	_, err := r.db.Exec(context.Background(), query, args...)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	return r.GetCollectionByID(id)
+}
+
+// DeleteCollection removes a collection; its memberships are removed by the cascade.
+func (r collectionRepository) DeleteCollection(id int) error {
+	_, err := r.db.Exec(context.Background(), "DELETE FROM collections WHERE id = $1", id)
+	return err
+}
+
+// AddBookmarkToCollection appends a bookmark to the end of a collection. Adding an existing member is a no-op.
+func (r collectionRepository) AddBookmarkToCollection(collectionID int, bookmarkID int) error {
+	_, err := r.db.Exec(context.Background(),
+		`INSERT INTO collections_bookmarks (collection_id, bookmark_id, position, created_at)
+		 SELECT $1, $2, COALESCE(MAX(position), 0) + 1, NOW()
+		 FROM collections_bookmarks WHERE collection_id = $1
+		 ON CONFLICT (collection_id, bookmark_id) DO NOTHING`,
+		collectionID, bookmarkID,
+	)
+	return err
+}
+
+// RemoveBookmarkFromCollection removes a bookmark from a collection.
+func (r collectionRepository) RemoveBookmarkFromCollection(collectionID int, bookmarkID int) error {
+	_, err := r.db.Exec(context.Background(),
+		`DELETE FROM collections_bookmarks WHERE collection_id = $1 AND bookmark_id = $2`,
+		collectionID, bookmarkID,
+	)
+	return err
+}
+
+// RemoveBookmarkFromOtherCollections removes a bookmark from every collection of the user except keepCollectionID.
+func (r collectionRepository) RemoveBookmarkFromOtherCollections(userID int, bookmarkID int, keepCollectionID int) error {
+	_, err := r.db.Exec(context.Background(),
+		`DELETE FROM collections_bookmarks
+		 WHERE bookmark_id = $1 AND collection_id <> $2
+		 AND collection_id IN (SELECT id FROM collections WHERE user_id = $3)`,
+		bookmarkID, keepCollectionID, userID,
+	)
+	return err
+}
+
+// ReorderCollection sets the position of each bookmark to its index in bookmarkIDs in a single transaction.
+// Members not listed keep their relative order after the listed ones.
+func (r collectionRepository) ReorderCollection(collectionID int, bookmarkIDs []int) error {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	// Move unlisted members behind the listed ones, keeping their order
+	_, err = tx.Exec(ctx,
+		`UPDATE collections_bookmarks SET position = position + $2
+		 WHERE collection_id = $1 AND NOT (bookmark_id = ANY($3))`,
+		collectionID, len(bookmarkIDs), bookmarkIDs,
+	)
+	if err != nil {
+		return err
+	}
+	for i, bookmarkID := range bookmarkIDs {
+		_, err := tx.Exec(ctx,
+			`UPDATE collections_bookmarks SET position = $1 WHERE collection_id = $2 AND bookmark_id = $3`,
+			i+1, collectionID, bookmarkID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListBookmarksInCollection retrieves a paginated list of a collection's bookmarks in their manual order.
+func (r collectionRepository) ListBookmarksInCollection(collectionID int, filter BookmarkFilter, offset int, limit int) ([]models.Bookmark, error) {
+	where, args := filter.whereClause([]string{"cb.collection_id = $1"}, []interface{}{collectionID})
+	args = append(args, limit, offset)
+	query := `
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks b
+		INNER JOIN collections_bookmarks cb ON b.id = cb.bookmark_id
+		` + where + `
+		ORDER BY cb.position ASC, cb.created_at ASC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bookmarks []models.Bookmark
+	for rows.Next() {
+		bookmark, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return bookmarks, nil
+}
+
+// NewCollectionRepository creates a new instance of collectionRepository.
+func NewCollectionRepository(db DBTX) CollectionRepository {
+	return &collectionRepository{db: db}
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrEmailAddressNotFound is returned for addresses not registered to an active user.
+var ErrEmailAddressNotFound = errors.New("email address not found")
+
+// ErrEmailAddressTaken is returned when an address is already confirmed, by the same or another user.
+var ErrEmailAddressTaken = errors.New("email address already registered")
+
+// ErrEmailConfirmationInvalid is returned for confirmation codes that are unknown, expired or belong to another user.
+var ErrEmailConfirmationInvalid = errors.New("invalid or expired confirmation code")
+
+// EmailRepository handles the sender addresses users save bookmarks from by email
+type EmailRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewEmailRepository(db *pgxpool.Pool) *EmailRepository {
+	return &EmailRepository{DB: db}
+}
+
+// AddAddress registers an unconfirmed address for the user with the hash of its confirmation code; address must
+// already be normalized. Adding an address the user has not confirmed yet replaces its code. Unconfirmed claims of
+// other users do not block anyone, so an address cannot be squatted by registering it first.
+func (r *EmailRepository) AddAddress(userID int, address, codeHash string, expiresAt time.Time) (models.EmailAddress, error) {
+	ctx := context.Background()
+	var taken bool
+	err := r.DB.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM email_addresses WHERE address = $1 AND verified_at IS NOT NULL)`, address,
+	).Scan(&taken)
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	if taken {
+		return models.EmailAddress{}, ErrEmailAddressTaken
+	}
+	var a models.EmailAddress
+	err = r.DB.QueryRow(ctx,
+		`INSERT INTO email_addresses (user_id, address, verification_code_hash, verification_expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (user_id, address) DO UPDATE
+		 SET verification_code_hash = EXCLUDED.verification_code_hash, verification_expires_at = EXCLUDED.verification_expires_at
+		 WHERE email_addresses.verified_at IS NULL
+		 RETURNING id, user_id, address, verified_at, created_at`,
+		userID, address, codeHash, expiresAt, time.Now().UTC(),
+	).Scan(&a.ID, &a.UserID, &a.Address, &a.VerifiedAt, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.EmailAddress{}, ErrEmailAddressTaken
+	}
+	return a, err
+}
+
+// ConfirmAddress confirms the user's address whose unexpired code hashes to codeHash and drops other users'
+// unconfirmed claims of it
+func (r *EmailRepository) ConfirmAddress(userID int, codeHash string, now time.Time) (models.EmailAddress, error) {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var a models.EmailAddress
+	err = tx.QueryRow(ctx,
+		`SELECT id, user_id, address, created_at FROM email_addresses
+		 WHERE user_id = $1 AND verification_code_hash = $2 AND verified_at IS NULL AND verification_expires_at > $3
+		 FOR UPDATE`,
+		userID, codeHash, now,
+	).Scan(&a.ID, &a.UserID, &a.Address, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.EmailAddress{}, ErrEmailConfirmationInvalid
+	}
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	var taken bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM email_addresses WHERE address = $1 AND verified_at IS NOT NULL)`, a.Address,
+	).Scan(&taken)
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	if taken {
+		return models.EmailAddress{}, ErrEmailAddressTaken
+	}
+	err = tx.QueryRow(ctx,
+		`UPDATE email_addresses SET verified_at = $2, verification_code_hash = NULL, verification_expires_at = NULL
+		 WHERE id = $1 RETURNING verified_at`,
+		a.ID, now,
+	).Scan(&a.VerifiedAt)
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM email_addresses WHERE address = $1 AND verified_at IS NULL`, a.Address); err != nil {
+		return models.EmailAddress{}, err
+	}
+	return a, tx.Commit(ctx)
+}
+
+// DeleteExpiredClaims removes unconfirmed addresses whose confirmation code expired before now
+func (r *EmailRepository) DeleteExpiredClaims(now time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(),
+		`DELETE FROM email_addresses WHERE verified_at IS NULL AND verification_expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *EmailRepository) ListAddresses(userID int) ([]models.EmailAddress, error) {
+	rows, err := r.DB.Query(context.Background(),
+		`SELECT id, user_id, address, verified_at, created_at FROM email_addresses WHERE user_id = $1 ORDER BY address`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var addresses []models.EmailAddress
+	for rows.Next() {
+		var a models.EmailAddress
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Address, &a.VerifiedAt, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return addresses, nil
+}
+
+// DeleteAddress removes one of the user's addresses
+func (r *EmailRepository) DeleteAddress(userID int, id int64) error {
+	tag, err := r.DB.Exec(context.Background(),
+		`DELETE FROM email_addresses WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrEmailAddressNotFound
+	}
+	return nil
+}
+
+// GetAddressUserID returns the user who confirmed an address; unconfirmed addresses and those of disabled users count as unknown
+func (r *EmailRepository) GetAddressUserID(address string) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT a.user_id FROM email_addresses a JOIN users u ON u.id = a.user_id
+		 WHERE a.address = $1 AND a.verified_at IS NOT NULL AND u.disabled_at IS NULL`, address,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrEmailAddressNotFound
+	}
+	return userID, err
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HighlightRepository defines the interface for handling quoted highlights on bookmarks.
+type HighlightRepository interface {
+	CreateHighlight(bookmarkID int, quote string, note *string) (models.Highlight, error)
+	ListHighlights(bookmarkID int) ([]models.Highlight, error)
+	DeleteHighlight(bookmarkID int, id int) error
+}
+
+type highlightRepository struct {
+	db *pgxpool.Pool
+}
+
+// CreateHighlight adds a highlight to a bookmark.
+func (r highlightRepository) CreateHighlight(bookmarkID int, quote string, note *string) (models.Highlight, error) {
+	createdAt := time.Now().UTC()
+	var id int64
+	err := r.db.QueryRow(context.Background(),
+		`INSERT INTO bookmark_highlights (bookmark_id, quote, note, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		bookmarkID, quote, note, createdAt, createdAt,
+	).Scan(&id)
+	if err != nil {
+		return models.Highlight{}, err
+	}
+	return models.Highlight{
+		ID:         id,
+		BookmarkID: int64(bookmarkID),
+		Quote:      quote,
+		Note:       note,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}, nil
+}
+
+// ListHighlights retrieves the highlights of a bookmark in the order they were made.
+func (r highlightRepository) ListHighlights(bookmarkID int) ([]models.Highlight, error) {
+	rows, err := r.db.Query(context.Background(),
+		`SELECT id, bookmark_id, quote, note, created_at, updated_at
+		 FROM bookmark_highlights WHERE bookmark_id = $1 ORDER BY created_at ASC`, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var highlights []models.Highlight
+	for rows.Next() {
+		var h models.Highlight
+		err := rows.Scan(&h.ID, &h.BookmarkID, &h.Quote, &h.Note, &h.CreatedAt, &h.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		highlights = append(highlights, h)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return highlights, nil
+}
+
+// DeleteHighlight removes a highlight from a bookmark.
+func (r highlightRepository) DeleteHighlight(bookmarkID int, id int) error {
+	_, err := r.db.Exec(context.Background(),
+		`DELETE FROM bookmark_highlights WHERE bookmark_id = $1 AND id = $2`, bookmarkID, id)
+	return err
+}
+
+// NewHighlightRepository creates a new instance of highlightRepository.
+func NewHighlightRepository(db *pgxpool.Pool) HighlightRepository {
+	return &highlightRepository{db: db}
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrInboundHookNotFound is returned for unknown hooks and hooks of disabled users.
+var ErrInboundHookNotFound = errors.New("inbound hook not found")
+
+// InboundHookRepository handles the signed webhooks users save bookmarks through
+type InboundHookRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewInboundHookRepository(db *pgxpool.Pool) *InboundHookRepository {
+	return &InboundHookRepository{DB: db}
+}
+
+func (r *InboundHookRepository) CreateHook(hook models.InboundHook) error {
+	_, err := r.DB.Exec(context.Background(),
+		`INSERT INTO inbound_hooks (id, user_id, name, secret, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		hook.ID, hook.UserID, hook.Name, hook.Secret, hook.CreatedAt)
+	return err
+}
+
+// GetHook returns a hook including its secret
+func (r *InboundHookRepository) GetHook(id string) (models.InboundHook, error) {
+	var hook models.InboundHook
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT h.id, h.user_id, h.name, h.secret, h.created_at, h.last_used_at
+		 FROM inbound_hooks h JOIN users u ON u.id = h.user_id
+		 WHERE h.id = $1 AND u.disabled_at IS NULL`, id,
+	).Scan(&hook.ID, &hook.UserID, &hook.Name, &hook.Secret, &hook.CreatedAt, &hook.LastUsedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.InboundHook{}, ErrInboundHookNotFound
+	}
+	return hook, err
+}
+
+// ListHooks returns the user's hooks without their secrets
+func (r *InboundHookRepository) ListHooks(userID int) ([]models.InboundHook, error) {
+	rows, err := r.DB.Query(context.Background(),
+		`SELECT id, user_id, name, created_at, last_used_at FROM inbound_hooks WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hooks []models.InboundHook
+	for rows.Next() {
+		var hook models.InboundHook
+		if err := rows.Scan(&hook.ID, &hook.UserID, &hook.Name, &hook.CreatedAt, &hook.LastUsedAt); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return hooks, nil
+}
+
+// DeleteHook removes one of the user's hooks
+func (r *InboundHookRepository) DeleteHook(userID int, id string) error {
+	tag, err := r.DB.Exec(context.Background(), `DELETE FROM inbound_hooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInboundHookNotFound
+	}
+	return nil
+}
+
+func (r *InboundHookRepository) TouchHook(id string) error {
+	_, err := r.DB.Exec(context.Background(), `UPDATE inbound_hooks SET last_used_at = $2 WHERE id = $1`, id, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrLinkCodeNotFound is returned for unknown, already used or expired link codes, and for codes issued for another provider.
+var ErrLinkCodeNotFound = errors.New("link code not found")
+
+// LinkCodeRepository handles the one-time codes that link a Telegram chat, Slack account or Discord account to a user
+type LinkCodeRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewLinkCodeRepository(db *pgxpool.Pool) *LinkCodeRepository {
+	return &LinkCodeRepository{DB: db}
+}
+
+// CreateLinkCode stores a new link code for the user and provider, replacing any code for it they had not used yet
+func (r *LinkCodeRepository) CreateLinkCode(userID int, provider string, codeHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM link_codes WHERE user_id = $1 AND provider = $2`, userID, provider); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO link_codes (code_hash, user_id, provider, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		codeHash, userID, provider, expiresAt, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteExpiredLinkCodes removes link codes that expired before now
+func (r *LinkCodeRepository) DeleteExpiredLinkCodes(now time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(), `DELETE FROM link_codes WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// consumeLinkCode uses up an unexpired code issued for provider and returns its user
+func consumeLinkCode(ctx context.Context, tx pgx.Tx, provider string, codeHash string) (int64, error) {
+	var userID int64
+	err := tx.QueryRow(ctx,
+		`DELETE FROM link_codes WHERE code_hash = $1 AND provider = $2 AND expires_at > $3 RETURNING user_id`,
+		codeHash, provider, time.Now().UTC(),
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrLinkCodeNotFound
+	}
+	return userID, err
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LoginThrottleRepository tracks failed logins per username and per IP address
+type LoginThrottleRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewLoginThrottleRepository(db *pgxpool.Pool) *LoginThrottleRepository {
+	return &LoginThrottleRepository{DB: db}
+}
+
+// GetThrottle returns the failure count for a key; keys without failures get a zero count
+func (r *LoginThrottleRepository) GetThrottle(scope, key string) (models.LoginThrottle, error) {
+	t := models.LoginThrottle{Scope: scope, Key: key}
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT failures, last_failure_at, locked_until FROM login_throttles WHERE scope = $1 AND key = $2`,
+		scope, key,
+	).Scan(&t.Failures, &t.LastFailureAt, &t.LockedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return t, nil
+	}
+	return t, err
+}
+
+// RecordFailure counts a failed login and returns the new count.
+// A key whose last failure is older than resetBefore starts counting again from one.
+func (r *LoginThrottleRepository) RecordFailure(scope, key string, now time.Time, resetBefore time.Time) (int, error) {
+	var failures int
+	err := r.DB.QueryRow(context.Background(),
+		`INSERT INTO login_throttles (scope, key, failures, last_failure_at) VALUES ($1, $2, 1, $3)
+		 ON CONFLICT (scope, key) DO UPDATE SET
+		   failures = CASE WHEN login_throttles.last_failure_at < $4 THEN 1 ELSE login_throttles.failures + 1 END,
+		   last_failure_at = $3
+		 RETURNING failures`,
+		scope, key, now, resetBefore,
+	).Scan(&failures)
+	return failures, err
+}
+
+// SetLockedUntil blocks further attempts for a key until the given time
+func (r *LoginThrottleRepository) SetLockedUntil(scope, key string, lockedUntil time.Time) error {
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE login_throttles SET locked_until = $1 WHERE scope = $2 AND key = $3`, lockedUntil, scope, key)
+	return err
+}
+
+// ResetThrottle forgets the failures of a key after a successful login
+func (r *LoginThrottleRepository) ResetThrottle(scope, key string) error {
+	_, err := r.DB.Exec(context.Background(),
+		`DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key)
+	return err
+}
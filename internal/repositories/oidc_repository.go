@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrIdentityNotFound is returned when no user is linked to a provider account.
+var ErrIdentityNotFound = errors.New("identity not found")
+
+// ErrLoginStateNotFound is returned for an unknown or already used OIDC login state.
+var ErrLoginStateNotFound = errors.New("login state not found")
+
+// OIDCRepository handles pending OIDC logins and the identities linked to users
+type OIDCRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewOIDCRepository(db *pgxpool.Pool) *OIDCRepository {
+	return &OIDCRepository{DB: db}
+}
+
+func (r *OIDCRepository) CreateLoginState(state models.OIDCLoginState) error {
+	_, err := r.DB.Exec(context.Background(),
+		`INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, redirect_path, link_user_id, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		state.StateHash, state.CodeVerifier, state.Nonce, state.RedirectPath, state.LinkUserID, state.ExpiresAt, time.Now().UTC())
+	return err
+}
+
+// ConsumeLoginState deletes and returns a login state, so each state completes at most one login
+func (r *OIDCRepository) ConsumeLoginState(stateHash string) (models.OIDCLoginState, error) {
+	var state models.OIDCLoginState
+	err := r.DB.QueryRow(context.Background(),
+		`DELETE FROM oidc_login_states WHERE state_hash = $1
+		 RETURNING state_hash, code_verifier, nonce, redirect_path, link_user_id, expires_at`, stateHash,
+	).Scan(&state.StateHash, &state.CodeVerifier, &state.Nonce, &state.RedirectPath, &state.LinkUserID, &state.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return state, ErrLoginStateNotFound
+	}
+	return state, err
+}
+
+func (r *OIDCRepository) DeleteExpiredLoginStates(now time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(), `DELETE FROM oidc_login_states WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *OIDCRepository) GetIdentity(issuer, subject string) (models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT id, user_id, issuer, subject, email, created_at, last_login_at
+		 FROM user_identities WHERE issuer = $1 AND subject = $2`, issuer, subject,
+	).Scan(&identity.ID, &identity.UserID, &identity.Issuer, &identity.Subject, &identity.Email,
+		&identity.CreatedAt, &identity.LastLoginAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return identity, ErrIdentityNotFound
+	}
+	return identity, err
+}
+
+func (r *OIDCRepository) CreateIdentity(userID int64, issuer, subject string, email *string) (models.UserIdentity, error) {
+	now := time.Now().UTC()
+	identity := models.UserIdentity{
+		UserID:      userID,
+		Issuer:      issuer,
+		Subject:     subject,
+		Email:       email,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	}
+	err := r.DB.QueryRow(context.Background(),
+		`INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		userID, issuer, subject, email, now, now,
+	).Scan(&identity.ID)
+	return identity, err
+}
+
+// TouchIdentity records a login through the identity and keeps its email current
+func (r *OIDCRepository) TouchIdentity(id int64, email *string) error {
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE user_identities SET email = $1, last_login_at = $2 WHERE id = $3`, email, time.Now().UTC(), id)
+	return err
+}
@@ -16,28 +16,53 @@ func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
 	return &RefreshTokenRepository{DB: db}
 }
 
-func (r *RefreshTokenRepository) CreateRefreshToken(userID int, refreshToken string, expiresAt time.Time) error {
+// CreateRefreshToken stores the hash of a refresh token; the token itself is never written to the database
+func (r *RefreshTokenRepository) CreateRefreshToken(userID int, sessionID int, refreshToken string, expiresAt time.Time) error {
 	createdAt := time.Now().UTC()
 	_, err := r.DB.Exec(context.Background(),
-		"INSERT INTO refresh_tokens (user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)",
-		userID, refreshToken, expiresAt, createdAt, createdAt,
+		"INSERT INTO refresh_tokens (user_id, session_id, refresh_token_hash, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		userID, sessionID, HashToken(refreshToken), expiresAt, createdAt, createdAt,
 	)
 	return err
 }
 
+// FindByToken looks up a refresh token by its hash
 func (r *RefreshTokenRepository) FindByToken(refreshToken string) (*models.RefreshToken, error) {
 	var t models.RefreshToken
 	err := r.DB.QueryRow(context.Background(),
-		"SELECT id, user_id, refresh_token, expires_at, created_at, updated_at FROM refresh_tokens WHERE refresh_token = $1",
-		refreshToken,
-	).Scan(&t.ID, &t.UserID, &t.RefreshToken, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt)
+		"SELECT id, user_id, session_id, refresh_token_hash, expires_at, created_at, updated_at, used_at FROM refresh_tokens WHERE refresh_token_hash = $1",
+		HashToken(refreshToken),
+	).Scan(&t.ID, &t.UserID, &t.SessionID, &t.RefreshTokenHash, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt, &t.UsedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
+// MarkUsed records that a refresh token has been exchanged. It reports false if the token
+// was already used, so two concurrent refreshes with the same token cannot both succeed.
+func (r *RefreshTokenRepository) MarkUsed(refreshToken string) (bool, error) {
+	usedAt := time.Now().UTC()
+	tag, err := r.DB.Exec(context.Background(),
+		"UPDATE refresh_tokens SET used_at = $1, updated_at = $1 WHERE refresh_token_hash = $2 AND used_at IS NULL",
+		usedAt, HashToken(refreshToken),
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *RefreshTokenRepository) DeleteRefreshToken(refreshToken string) error {
-	_, err := r.DB.Exec(context.Background(), "DELETE FROM refresh_tokens WHERE refresh_token = $1", refreshToken)
+	_, err := r.DB.Exec(context.Background(), "DELETE FROM refresh_tokens WHERE refresh_token_hash = $1", HashToken(refreshToken))
 	return err
 }
+
+// DeleteExpired removes refresh tokens, used or not, that expired before now
+func (r *RefreshTokenRepository) DeleteExpired(now time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(), "DELETE FROM refresh_tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
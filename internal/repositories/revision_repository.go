@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// RevisionRepository defines the interface for the edit history of bookmarks.
+type RevisionRepository interface {
+	CreateRevision(revision models.BookmarkRevision) (models.BookmarkRevision, error)
+	// ListRevisions retrieves the revisions of a bookmark, newest first
+	ListRevisions(bookmarkID int) ([]models.BookmarkRevision, error)
+	// ListRevisionsSince retrieves a revision and every later one of the same bookmark, newest first
+	ListRevisionsSince(bookmarkID int, revisionID int) ([]models.BookmarkRevision, error)
+}
+
+type revisionRepository struct {
+	db DBTX
+}
+
+// CreateRevision stores a revision; field and tag changes are kept as JSON.
+func (r revisionRepository) CreateRevision(revision models.BookmarkRevision) (models.BookmarkRevision, error) {
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return models.BookmarkRevision{}, err
+	}
+	var tags []byte
+	if revision.Tags != nil {
+		tags, err = json.Marshal(revision.Tags)
+		if err != nil {
+			return models.BookmarkRevision{}, err
+		}
+	}
+	revision.CreatedAt = time.Now().UTC()
+	err = r.db.QueryRow(context.Background(),
+		`INSERT INTO bookmark_revisions (bookmark_id, user_id, changes, tags, created_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		revision.BookmarkID, revision.UserID, string(changes), nullableJSON(tags), revision.CreatedAt,
+	).Scan(&revision.ID)
+	if err != nil {
+		return models.BookmarkRevision{}, err
+	}
+	return revision, nil
+}
+
+// ListRevisions retrieves the revisions of a bookmark, newest first.
+func (r revisionRepository) ListRevisions(bookmarkID int) ([]models.BookmarkRevision, error) {
+	return r.queryRevisions(
+		`SELECT id, bookmark_id, user_id, changes, tags, created_at
+		 FROM bookmark_revisions WHERE bookmark_id = $1 ORDER BY id DESC`, bookmarkID)
+}
+
+// ListRevisionsSince retrieves a revision and every later one of the same bookmark, newest first.
+func (r revisionRepository) ListRevisionsSince(bookmarkID int, revisionID int) ([]models.BookmarkRevision, error) {
+	return r.queryRevisions(
+		`SELECT id, bookmark_id, user_id, changes, tags, created_at
+		 FROM bookmark_revisions WHERE bookmark_id = $1 AND id >= $2 ORDER BY id DESC`, bookmarkID, revisionID)
+}
+
+func (r revisionRepository) queryRevisions(query string, args ...interface{}) ([]models.BookmarkRevision, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revisions []models.BookmarkRevision
+	for rows.Next() {
+		var rev models.BookmarkRevision
+		var changes, tags []byte
+		if err := rows.Scan(&rev.ID, &rev.BookmarkID, &rev.UserID, &changes, &tags, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &rev.Changes); err != nil {
+			return nil, err
+		}
+		if tags != nil {
+			rev.Tags = &models.TagChange{}
+			if err := json.Unmarshal(tags, rev.Tags); err != nil {
+				return nil, err
+			}
+		}
+		revisions = append(revisions, rev)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return revisions, nil
+}
+
+// nullableJSON maps an empty document to SQL NULL
+func nullableJSON(doc []byte) *string {
+	if doc == nil {
+		return nil
+	}
+	s := string(doc)
+	return &s
+}
+
+// NewRevisionRepository creates a new instance of revisionRepository.
+func NewRevisionRepository(db DBTX) RevisionRepository {
+	return &revisionRepository{db: db}
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sessionTouchInterval limits how often last_used_at is written, so authenticated requests don't all cause a write
+const sessionTouchInterval = time.Minute
+
+// SessionRepository handles login sessions; deleting a session deletes its tokens
+type SessionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+func (r *SessionRepository) CreateSession(userID int, userAgent, ipAddress string) (models.Session, error) {
+	createdAt := time.Now().UTC()
+	var id int64
+	err := r.DB.QueryRow(context.Background(),
+		`INSERT INTO sessions (user_id, user_agent, ip_address, created_at, last_used_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		userID, userAgent, ipAddress, createdAt, createdAt,
+	).Scan(&id)
+	if err != nil {
+		return models.Session{}, err
+	}
+	return models.Session{
+		ID:         id,
+		UserID:     int64(userID),
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  createdAt,
+		LastUsedAt: createdAt,
+	}, nil
+}
+
+func (r *SessionRepository) GetSessionByID(id int) (models.Session, error) {
+	var s models.Session
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT id, user_id, user_agent, ip_address, created_at, last_used_at FROM sessions WHERE id = $1`, id,
+	).Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt)
+	return s, err
+}
+
+// ListSessions retrieves the user's sessions, most recently used first
+func (r *SessionRepository) ListSessions(userID int) ([]models.Session, error) {
+	rows, err := r.DB.Query(context.Background(),
+		`SELECT id, user_id, user_agent, ip_address, created_at, last_used_at
+		 FROM sessions WHERE user_id = $1 ORDER BY last_used_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return sessions, nil
+}
+
+// TouchSession records that the session was just used, at most once per sessionTouchInterval.
+// An empty ipAddress keeps the stored one.
+func (r *SessionRepository) TouchSession(id int, ipAddress string) error {
+	now := time.Now().UTC()
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE sessions SET last_used_at = $1, ip_address = COALESCE(NULLIF($2, ''), ip_address)
+		 WHERE id = $3 AND last_used_at < $4`,
+		now, ipAddress, id, now.Add(-sessionTouchInterval),
+	)
+	return err
+}
+
+// DeleteSession revokes a session together with its access and refresh tokens
+func (r *SessionRepository) DeleteSession(id int) error {
+	_, err := r.DB.Exec(context.Background(), "DELETE FROM sessions WHERE id = $1", id)
+	return err
+}
+
+// DeleteUserSessions revokes every session of the user except exceptID (0 keeps none)
+func (r *SessionRepository) DeleteUserSessions(userID int, exceptID int) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(), "DELETE FROM sessions WHERE user_id = $1 AND id <> $2", userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteAbandoned removes sessions created before createdBefore that no longer have a refresh token,
+// so they can never be used again. The cutoff spares sessions whose tokens are still being issued.
+func (r *SessionRepository) DeleteAbandoned(createdBefore time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(),
+		`DELETE FROM sessions s WHERE s.created_at < $1
+		 AND NOT EXISTS (SELECT 1 FROM refresh_tokens rt WHERE rt.session_id = s.id)`, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
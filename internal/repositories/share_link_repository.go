@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ShareLinkRepository defines the interface for handling public share links.
+type ShareLinkRepository interface {
+	CreateShareLink(link models.ShareLink) (models.ShareLink, error)
+	// GetShareLinkByToken returns an active (not revoked) share link
+	GetShareLinkByToken(token string) (models.ShareLink, error)
+	GetShareLinkByID(id int) (models.ShareLink, error)
+	ListShareLinks(userID int) ([]models.ShareLink, error)
+	RevokeShareLink(id int) error
+}
+
+type shareLinkRepository struct {
+	db *pgxpool.Pool
+}
+
+const shareLinkColumns = `id, user_id, token, kind, collection_id, tag_id, query, title, created_at, revoked_at`
+
+// CreateShareLink stores a new share link.
+func (r shareLinkRepository) CreateShareLink(link models.ShareLink) (models.ShareLink, error) {
+	link.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRow(context.Background(),
+		`INSERT INTO share_links (user_id, token, kind, collection_id, tag_id, query, title, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		link.UserID, link.Token, link.Kind, link.CollectionID, link.TagID, link.Query, link.Title, link.CreatedAt,
+	).Scan(&link.ID)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	return link, nil
+}
+
+// GetShareLinkByToken retrieves an active share link by its token.
+func (r shareLinkRepository) GetShareLinkByToken(token string) (models.ShareLink, error) {
+	row := r.db.QueryRow(context.Background(),
+		`SELECT `+shareLinkColumns+` FROM share_links WHERE token = $1 AND revoked_at IS NULL`, token)
+	return scanShareLink(row)
+}
+
+// GetShareLinkByID retrieves a share link by its ID.
+func (r shareLinkRepository) GetShareLinkByID(id int) (models.ShareLink, error) {
+	row := r.db.QueryRow(context.Background(),
+		`SELECT `+shareLinkColumns+` FROM share_links WHERE id = $1`, id)
+	return scanShareLink(row)
+}
+
+// ListShareLinks retrieves all share links created by a user, newest first.
+func (r shareLinkRepository) ListShareLinks(userID int) ([]models.ShareLink, error) {
+	rows, err := r.db.Query(context.Background(),
+		`SELECT `+shareLinkColumns+` FROM share_links WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var links []models.ShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return links, nil
+}
+
+// RevokeShareLink marks a share link as revoked so its URL stops working.
+func (r shareLinkRepository) RevokeShareLink(id int) error {
+	_, err := r.db.Exec(context.Background(),
+		`UPDATE share_links SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, time.Now().UTC(), id)
+	return err
+}
+
+// rowScanner is implemented by both pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanShareLink(row rowScanner) (models.ShareLink, error) {
+	var link models.ShareLink
+	err := row.Scan(&link.ID, &link.UserID, &link.Token, &link.Kind, &link.CollectionID, &link.TagID,
+		&link.Query, &link.Title, &link.CreatedAt, &link.RevokedAt)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	return link, nil
+}
+
+// NewShareLinkRepository creates a new instance of shareLinkRepository.
+func NewShareLinkRepository(db *pgxpool.Pool) ShareLinkRepository {
+	return &shareLinkRepository{db: db}
+}
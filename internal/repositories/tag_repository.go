@@ -6,40 +6,59 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// TagHierarchySeparator separates the levels of a hierarchical tag name, e.g. "lang/go".
+const TagHierarchySeparator = "/"
+
 type TagRepository interface {
 	CreateTag(name string) (models.Tag, error)
+	CreateTagWithParent(name string, parentID *int64) (models.Tag, error)
 	AddTagToBookmark(bookmarkID int, tagID int) error
 	GetTagsForBookmark(bookmarkID int) ([]models.BookmarkTag, error)
 	GetAndCreateTagsIfMissing(tagNames []string) ([]models.Tag, error)
 	GetTagByName(name string) (models.Tag, error)
+	GetTagByID(id int) (models.Tag, error)
+	// TagNameInUse reports whether a tag or alias is named name or lies below it in the hierarchy
+	TagNameInUse(name string) (bool, error)
+	// RenameTag renames a tag and moves the names of its descendants along with it
+	RenameTag(id int, name string) (models.Tag, error)
 	RemoveAllTagsFromBookmark(bookmarkID int) error
 	ListAllTags() ([]models.Tag, error)
 	ListTags(page int, limit int) ([]models.Tag, error)
+	// ListTagCounts retrieves the most used tags among the bookmarks matching the filter
+	ListTagCounts(filter BookmarkFilter, limit int) ([]models.TagCount, error)
+	ListTagTree() ([]models.TagNode, error)
+	CreateTagAlias(alias string, tagID int) (models.TagAlias, error)
+	DeleteTagAlias(alias string) error
+	ListTagAliases() ([]models.TagAlias, error)
+	ResolveTagAliases(names []string) (map[string]string, error)
 }
 
 type tagRepository struct {
-	db *pgxpool.Pool
+	db DBTX
 }
 
-// CreateTag adds a new tag to the database.
+// CreateTag adds a new top-level tag to the database.
 func (r tagRepository) CreateTag(name string) (models.Tag, error) {
+	return r.CreateTagWithParent(name, nil)
+}
+
+// CreateTagWithParent adds a new tag to the database under the given parent tag.
+func (r tagRepository) CreateTagWithParent(name string, parentID *int64) (models.Tag, error) {
 	ts := time.Now().UTC()
 	var tagID int64
 	err := r.db.QueryRow(context.Background(),
-		`INSERT INTO tags (name, created_at, updated_at) VALUES ($1, $2, $3) RETURNING id`,
-		name, ts, ts,
+		`INSERT INTO tags (name, parent_id, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		name, parentID, ts, ts,
 	).Scan(&tagID)
 	if err != nil {
 		return models.Tag{}, err
 	}
 	var tag models.Tag
 	err = r.db.QueryRow(context.Background(),
-		`SELECT id, name, created_at, updated_at FROM tags WHERE id = $1`, tagID,
-	).Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		`SELECT id, name, parent_id, created_at, updated_at FROM tags WHERE id = $1`, tagID,
+	).Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt, &tag.UpdatedAt)
 	if err != nil {
 		return models.Tag{}, err
 	}
@@ -86,71 +105,179 @@ func (r tagRepository) GetTagsForBookmark(bookmarkID int) ([]models.BookmarkTag,
 func (r tagRepository) GetTagByName(name string) (models.Tag, error) {
 	var tag models.Tag
 	err := r.db.QueryRow(context.Background(),
-		`SELECT id, name, created_at, updated_at FROM tags WHERE name = $1`, name,
-	).Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		`SELECT id, name, parent_id, created_at, updated_at FROM tags WHERE name = $1`, name,
+	).Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	return tag, nil
+}
+
+// GetTagByID fetches a tag by its ID.
+func (r tagRepository) GetTagByID(id int) (models.Tag, error) {
+	var tag models.Tag
+	err := r.db.QueryRow(context.Background(),
+		`SELECT id, name, parent_id, created_at, updated_at FROM tags WHERE id = $1`, id,
+	).Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt, &tag.UpdatedAt)
 	if err != nil {
 		return models.Tag{}, err
 	}
 	return tag, nil
 }
 
-// GetAndCreateTagsIfMissing accepts a slice of tag names, creates any missing tags, and returns all tag structs for the input names
+// RenameTag renames a tag. Descendants store their full path, so "lang/go" becomes "languages/go"
+// when "lang" is renamed to "languages"; parents are unchanged.
+func (r tagRepository) RenameTag(id int, name string) (models.Tag, error) {
+	tag, err := r.GetTagByID(id)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	_, err = r.db.Exec(context.Background(),
+		`UPDATE tags SET name = $2 || substr(name, length($3) + 1), updated_at = $5
+		 WHERE id = $1 OR left(name, length($3) + length($4)) = $3 || $4`,
+		id, name, tag.Name, TagHierarchySeparator, time.Now().UTC())
+	if err != nil {
+		return models.Tag{}, err
+	}
+	return r.GetTagByID(id)
+}
+
+// TagNameInUse reports whether a tag or alias is named name or starts with name and TagHierarchySeparator,
+// so renaming a tag to name would collide with it or with the new names of the tag's descendants
+func (r tagRepository) TagNameInUse(name string) (bool, error) {
+	var inUse bool
+	err := r.db.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM tags WHERE name = $1 OR left(name, length($1) + length($2)) = $1 || $2)
+		     OR EXISTS (SELECT 1 FROM tag_aliases WHERE alias = $1 OR left(alias, length($1) + length($2)) = $1 || $2)`,
+		name, TagHierarchySeparator,
+	).Scan(&inUse)
+	return inUse, err
+}
+
+// GetAndCreateTagsIfMissing accepts a slice of tag names, creates any missing tags, and returns all tag structs for the input names.
+// Names containing TagHierarchySeparator (e.g. "lang/go") also get every missing ancestor ("lang") created and linked as parents.
 func (r tagRepository) GetAndCreateTagsIfMissing(tagNames []string) ([]models.Tag, error) {
 	if len(tagNames) == 0 {
 		return nil, nil
 	}
-	// 1. Find which tags already exist
-	placeholders := make([]string, len(tagNames))
-	args := make([]interface{}, len(tagNames))
-	for i, name := range tagNames {
-		placeholders[i] = "$" + strconv.Itoa(i+1)
-		args[i] = name
+	// 1. Expand every name into its ancestor paths so the whole hierarchy exists
+	requested := make([]string, 0, len(tagNames))
+	allPaths := []string{}
+	seen := make(map[string]struct{})
+	for _, name := range tagNames {
+		paths := tagPaths(name)
+		if len(paths) == 0 {
+			continue
+		}
+		requested = append(requested, paths[len(paths)-1])
+		for _, p := range paths {
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				allPaths = append(allPaths, p)
+			}
+		}
 	}
-	query := `SELECT id, name, created_at, updated_at FROM tags WHERE name IN (` + strings.Join(placeholders, ",") + `)`
-	rows, err := r.db.Query(context.Background(), query, args...)
+	if len(allPaths) == 0 {
+		return nil, nil
+	}
+	// 2. Find which tags already exist
+	existingTags, err := r.getTagsByNames(allPaths)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	existingTags := make(map[string]models.Tag)
-	for rows.Next() {
-		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+	// 3. Create missing tags; ancestors always precede descendants in allPaths
+	for _, path := range allPaths {
+		var parentID *int64
+		if i := strings.LastIndex(path, TagHierarchySeparator); i > 0 {
+			parent := existingTags[path[:i]]
+			parentID = &parent.ID
+		}
+		if tag, found := existingTags[path]; found {
+			// Tags saved before hierarchies existed may still lack their parent link
+			if parentID != nil && tag.ParentID == nil {
+				if err := r.setTagParent(tag.ID, *parentID); err != nil {
+					return nil, err
+				}
+				tag.ParentID = parentID
+				existingTags[path] = tag
+			}
+			continue
+		}
+		tag, err := r.CreateTagWithParent(path, parentID)
 		if err != nil {
 			return nil, err
 		}
-		existingTags[tag.Name] = tag
+		existingTags[path] = tag
 	}
-	missing := []string{}
-	for _, name := range tagNames {
-		if _, found := existingTags[name]; !found {
-			missing = append(missing, name)
+	// Return tag structs for the input names only
+	var tags []models.Tag
+	added := make(map[string]struct{})
+	for _, name := range requested {
+		if _, ok := added[name]; ok {
+			continue
 		}
+		added[name] = struct{}{}
+		tags = append(tags, existingTags[name])
 	}
-	for _, name := range missing {
-		_, err := r.CreateTag(name)
-		if err != nil {
-			return nil, err
-		}
+	return tags, nil
+}
+
+// setTagParent links a tag to its parent tag
+func (r tagRepository) setTagParent(id int64, parentID int64) error {
+	_, err := r.db.Exec(context.Background(),
+		`UPDATE tags SET parent_id = $2, updated_at = $3 WHERE id = $1`, id, parentID, time.Now().UTC())
+	return err
+}
+
+// getTagsByNames returns the existing tags among names, keyed by name
+func (r tagRepository) getTagsByNames(names []string) (map[string]models.Tag, error) {
+	placeholders := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = name
 	}
-	// Query again to get all tag structs for input names
-	rows2, err := r.db.Query(context.Background(), query, args...)
+	query := `SELECT id, name, parent_id, created_at, updated_at FROM tags WHERE name IN (` + strings.Join(placeholders, ",") + `)`
+	rows, err := r.db.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows2.Close()
-	var tags []models.Tag
-	for rows2.Next() {
+	defer rows.Close()
+	tags := make(map[string]models.Tag)
+	for rows.Next() {
 		var tag models.Tag
-		err := rows2.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt, &tag.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
-		tags = append(tags, tag)
+		tags[tag.Name] = tag
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
 	}
 	return tags, nil
 }
 
+// tagPaths splits a hierarchical tag name into its cumulative paths,
+// e.g. "lang/go/generics" -> ["lang", "lang/go", "lang/go/generics"]. Empty segments are dropped.
+func tagPaths(name string) []string {
+	var paths []string
+	current := ""
+	for _, segment := range strings.Split(name, TagHierarchySeparator) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		if current == "" {
+			current = segment
+		} else {
+			current += TagHierarchySeparator + segment
+		}
+		paths = append(paths, current)
+	}
+	return paths
+}
+
 // RemoveAllTagsFromBookmark removes all tags associated with a bookmark.
 func (r tagRepository) RemoveAllTagsFromBookmark(bookmarkID int) error {
 	_, err := r.db.Exec(context.Background(), `DELETE FROM bookmarks_tags WHERE bookmark_id = $1`, bookmarkID)
@@ -159,7 +286,7 @@ func (r tagRepository) RemoveAllTagsFromBookmark(bookmarkID int) error {
 
 // ListAllTags retrieves all tags from the database (no pagination)
 func (r tagRepository) ListAllTags() ([]models.Tag, error) {
-	rows, err := r.db.Query(context.Background(), `SELECT id, name, created_at, updated_at FROM tags`)
+	rows, err := r.db.Query(context.Background(), `SELECT id, name, parent_id, created_at, updated_at FROM tags ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +294,7 @@ func (r tagRepository) ListAllTags() ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt, &tag.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -182,7 +309,7 @@ func (r tagRepository) ListAllTags() ([]models.Tag, error) {
 // ListTags retrieves paginated tags from the database
 func (r tagRepository) ListTags(page int, limit int) ([]models.Tag, error) {
 	offset := (page - 1) * limit
-	rows, err := r.db.Query(context.Background(), `SELECT id, name, created_at, updated_at FROM tags LIMIT $1 OFFSET $2`, limit, offset)
+	rows, err := r.db.Query(context.Background(), `SELECT id, name, parent_id, created_at, updated_at FROM tags LIMIT $1 OFFSET $2`, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -190,7 +317,7 @@ func (r tagRepository) ListTags(page int, limit int) ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt, &tag.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -202,7 +329,144 @@ func (r tagRepository) ListTags(page int, limit int) ([]models.Tag, error) {
 	return tags, nil
 }
 
+// ListTagCounts retrieves the most used tags among the bookmarks matching the filter, most used first
+func (r tagRepository) ListTagCounts(filter BookmarkFilter, limit int) ([]models.TagCount, error) {
+	where, args := filter.whereClause(nil, []interface{}{limit})
+	rows, err := r.db.Query(context.Background(),
+		`SELECT t.name, COUNT(*) FROM tags t
+		 JOIN bookmarks_tags bt ON bt.tag_id = t.id
+		 JOIN bookmarks b ON b.id = bt.bookmark_id
+		 `+where+`
+		 GROUP BY t.name ORDER BY COUNT(*) DESC, t.name LIMIT $1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []models.TagCount
+	for rows.Next() {
+		var count models.TagCount
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return counts, nil
+}
+
+// ListTagTree retrieves all tags arranged as a tree of top-level tags and their descendants
+func (r tagRepository) ListTagTree() ([]models.TagNode, error) {
+	tags, err := r.ListAllTags()
+	if err != nil {
+		return nil, err
+	}
+	childrenOf := make(map[int64][]models.Tag)
+	var roots []models.Tag
+	for _, tag := range tags {
+		if tag.ParentID == nil {
+			roots = append(roots, tag)
+		} else {
+			childrenOf[*tag.ParentID] = append(childrenOf[*tag.ParentID], tag)
+		}
+	}
+	var build func(tag models.Tag) models.TagNode
+	build = func(tag models.Tag) models.TagNode {
+		node := models.TagNode{Tag: tag, Children: []models.TagNode{}}
+		for _, child := range childrenOf[tag.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	tree := make([]models.TagNode, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree, nil
+}
+
+// CreateTagAlias maps alias to the tag with the given ID, replacing any existing mapping for the alias
+func (r tagRepository) CreateTagAlias(alias string, tagID int) (models.TagAlias, error) {
+	ts := time.Now().UTC()
+	var a models.TagAlias
+	err := r.db.QueryRow(context.Background(),
+		`INSERT INTO tag_aliases (alias, tag_id, created_at, updated_at) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (alias) DO UPDATE SET tag_id = EXCLUDED.tag_id, updated_at = EXCLUDED.updated_at
+		 RETURNING id, alias, tag_id, created_at, updated_at`,
+		alias, tagID, ts, ts,
+	).Scan(&a.ID, &a.Alias, &a.TagID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return models.TagAlias{}, err
+	}
+	err = r.db.QueryRow(context.Background(), `SELECT name FROM tags WHERE id = $1`, tagID).Scan(&a.TagName)
+	if err != nil {
+		return models.TagAlias{}, err
+	}
+	return a, nil
+}
+
+// DeleteTagAlias removes an alias mapping
+func (r tagRepository) DeleteTagAlias(alias string) error {
+	_, err := r.db.Exec(context.Background(), `DELETE FROM tag_aliases WHERE alias = $1`, alias)
+	return err
+}
+
+// ListTagAliases retrieves all alias mappings with their canonical tag names
+func (r tagRepository) ListTagAliases() ([]models.TagAlias, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT a.id, a.alias, a.tag_id, t.name, a.created_at, a.updated_at
+		FROM tag_aliases a
+		INNER JOIN tags t ON t.id = a.tag_id
+		ORDER BY a.alias`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var aliases []models.TagAlias
+	for rows.Next() {
+		var a models.TagAlias
+		err := rows.Scan(&a.ID, &a.Alias, &a.TagID, &a.TagName, &a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return aliases, nil
+}
+
+// ResolveTagAliases returns a map of alias to canonical tag name for every name that is a known alias
+func (r tagRepository) ResolveTagAliases(names []string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	if len(names) == 0 {
+		return resolved, nil
+	}
+	rows, err := r.db.Query(context.Background(), `
+		SELECT a.alias, t.name
+		FROM tag_aliases a
+		INNER JOIN tags t ON t.id = a.tag_id
+		WHERE a.alias = ANY($1)`, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var alias, name string
+		if err := rows.Scan(&alias, &name); err != nil {
+			return nil, err
+		}
+		resolved[alias] = name
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return resolved, nil
+}
+
 // NewTagRepository creates a new instance of tagRepository.
-func NewTagRepository(db *pgxpool.Pool) TagRepository {
+func NewTagRepository(db DBTX) TagRepository {
 	return &tagRepository{db: db}
 }
\ No newline at end of file
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrChatNotLinked is returned for Telegram chats not linked to an active user.
+var ErrChatNotLinked = errors.New("telegram chat not linked")
+
+// TelegramRepository handles Telegram chats linked to users
+type TelegramRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewTelegramRepository(db *pgxpool.Pool) *TelegramRepository {
+	return &TelegramRepository{DB: db}
+}
+
+// LinkChat uses up a Telegram link code and links the chat to the code's user, replacing an earlier link of the chat
+func (r *TelegramRepository) LinkChat(codeHash string, chatID int64) (models.TelegramChat, error) {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return models.TelegramChat{}, err
+	}
+	defer tx.Rollback(ctx)
+	userID, err := consumeLinkCode(ctx, tx, models.ChatProviderTelegram, codeHash)
+	if err != nil {
+		return models.TelegramChat{}, err
+	}
+	chat := models.TelegramChat{ChatID: chatID, UserID: userID, CreatedAt: time.Now().UTC()}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO telegram_chats (chat_id, user_id, created_at) VALUES ($1, $2, $3)
+		 ON CONFLICT (chat_id) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at`,
+		chat.ChatID, chat.UserID, chat.CreatedAt)
+	if err != nil {
+		return models.TelegramChat{}, err
+	}
+	return chat, tx.Commit(ctx)
+}
+
+// GetChatUserID returns the user a chat is linked to; chats of disabled users count as unlinked
+func (r *TelegramRepository) GetChatUserID(chatID int64) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT c.user_id FROM telegram_chats c JOIN users u ON u.id = c.user_id
+		 WHERE c.chat_id = $1 AND u.disabled_at IS NULL`, chatID,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrChatNotLinked
+	}
+	return userID, err
+}
+
+func (r *TelegramRepository) ListChats(userID int) ([]models.TelegramChat, error) {
+	rows, err := r.DB.Query(context.Background(),
+		`SELECT chat_id, user_id, created_at FROM telegram_chats WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var chats []models.TelegramChat
+	for rows.Next() {
+		var chat models.TelegramChat
+		if err := rows.Scan(&chat.ChatID, &chat.UserID, &chat.CreatedAt); err != nil {
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return chats, nil
+}
+
+// UnlinkChat removes the link of one of the user's chats
+func (r *TelegramRepository) UnlinkChat(userID int, chatID int64) error {
+	tag, err := r.DB.Exec(context.Background(),
+		`DELETE FROM telegram_chats WHERE chat_id = $1 AND user_id = $2`, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrChatNotLinked
+	}
+	return nil
+}
+
+// GetPollOffset returns the next update ID to fetch for the bot, 0 if it never polled
+func (r *TelegramRepository) GetPollOffset(botID int64) (int64, error) {
+	var offset int64
+	err := r.DB.QueryRow(context.Background(),
+		`SELECT next_offset FROM telegram_poll_offsets WHERE bot_id = $1`, botID).Scan(&offset)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return offset, err
+}
+
+func (r *TelegramRepository) SavePollOffset(botID int64, offset int64) error {
+	_, err := r.DB.Exec(context.Background(),
+		`INSERT INTO telegram_poll_offsets (bot_id, next_offset, updated_at) VALUES ($1, $2, $3)
+		 ON CONFLICT (bot_id) DO UPDATE SET next_offset = EXCLUDED.next_offset, updated_at = EXCLUDED.updated_at`,
+		botID, offset, time.Now().UTC())
+	return err
+}
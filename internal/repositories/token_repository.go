@@ -17,21 +17,23 @@ func NewTokenRepository(db *pgxpool.Pool) *TokenRepository {
 	return &TokenRepository{DB: db}
 }
 
-func (r *TokenRepository) CreateToken(userID int, token string, expiresAt time.Time) error {
+// CreateToken stores the hash of an access token; the token itself is never written to the database
+func (r *TokenRepository) CreateToken(userID int, sessionID int, token string, expiresAt time.Time) error {
 	createdAt := time.Now().UTC()
 	_, err := r.DB.Exec(context.Background(),
-		"INSERT INTO tokens (user_id, token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)",
-		userID, token, expiresAt, createdAt, createdAt,
+		"INSERT INTO tokens (user_id, session_id, token_hash, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		userID, sessionID, HashToken(token), expiresAt, createdAt, createdAt,
 	)
 	return err
 }
 
+// FindByToken looks up an access token by its hash
 func (r *TokenRepository) FindByToken(token string) (*models.Token, error) {
 	var t models.Token
 	err := r.DB.QueryRow(context.Background(),
-		"SELECT id, user_id, token, expires_at, created_at, updated_at FROM tokens WHERE token = $1",
-		token,
-	).Scan(&t.ID, &t.UserID, &t.Token, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt)
+		"SELECT id, user_id, session_id, token_hash, expires_at, created_at, updated_at FROM tokens WHERE token_hash = $1",
+		HashToken(token),
+	).Scan(&t.ID, &t.UserID, &t.SessionID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +41,15 @@ func (r *TokenRepository) FindByToken(token string) (*models.Token, error) {
 }
 
 func (r *TokenRepository) DeleteToken(token string) error {
-	_, err := r.DB.Exec(context.Background(), "DELETE FROM tokens WHERE token = $1", token)
+	_, err := r.DB.Exec(context.Background(), "DELETE FROM tokens WHERE token_hash = $1", HashToken(token))
 	return err
 }
+
+// DeleteExpired removes access tokens that expired before now
+func (r *TokenRepository) DeleteExpired(now time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(), "DELETE FROM tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -6,9 +6,13 @@ import (
 	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrUserNotFound is returned when no user matches the lookup
+var ErrUserNotFound = errors.New("user not found")
+
 // UserRepository handles user-related DB operations
 // Now uses pgxpool.Pool
 //
@@ -39,28 +43,241 @@ func (r *UserRepository) CreateUser(username, passwordHash string) (models.User,
 	}, nil
 }
 
-func (r *UserRepository) GetUserByUsername(username string) (models.User, error) {
-	row := r.DB.QueryRow(context.Background(),
-		`SELECT id, username, password_hash, created_at, updated_at FROM users WHERE username = $1`, username)
+// userColumns lists the columns read by scanUser
+const userColumns = `id, username, password_hash, is_admin, disabled_at, totp_secret, totp_enabled_at, totp_last_step, created_at, updated_at`
+
+func scanUser(row rowScanner) (models.User, error) {
 	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return user, errors.New("user not found")
-		}
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.IsAdmin, &user.DisabledAt,
+		&user.TOTPSecret, &user.TOTPEnabledAt, &user.TOTPLastStep, &user.CreatedAt, &user.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user, ErrUserNotFound
 	}
+	user.TwoFactorEnabled = user.TOTPEnabledAt != nil
 	return user, err
 }
 
+func (r *UserRepository) GetUserByUsername(username string) (models.User, error) {
+	return scanUser(r.DB.QueryRow(context.Background(),
+		`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+}
+
 func (r *UserRepository) GetUserByID(id int64) (models.User, error) {
-	row := r.DB.QueryRow(context.Background(),
-		`SELECT id, username, password_hash, created_at, updated_at FROM users WHERE id = $1`, id)
-	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	return scanUser(r.DB.QueryRow(context.Background(),
+		`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+// ListUsers retrieves all users ordered by username
+func (r *UserRepository) ListUsers() ([]models.User, error) {
+	rows, err := r.DB.Query(context.Background(), `SELECT `+userColumns+` FROM users ORDER BY username`)
 	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return user, errors.New("user not found")
+		return nil, err
+	}
+	defer rows.Close()
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
 		}
+		users = append(users, user)
 	}
-	return user, err
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return users, nil
+}
+
+func (r *UserRepository) UpdatePasswordHash(userID int64, passwordHash string) error {
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`, passwordHash, time.Now().UTC(), userID)
+	return err
+}
+
+func (r *UserRepository) SetAdmin(userID int64, isAdmin bool) error {
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE users SET is_admin = $1, updated_at = $2 WHERE id = $3`, isAdmin, time.Now().UTC(), userID)
+	return err
+}
+
+// SetDisabled disables or re-enables an account. Disabling also ends all of the user's sessions.
+func (r *UserRepository) SetDisabled(userID int64, disabled bool) error {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	now := time.Now().UTC()
+	var disabledAt *time.Time
+	if disabled {
+		disabledAt = &now
+	}
+	_, err = tx.Exec(ctx, `UPDATE users SET disabled_at = $1, updated_at = $2 WHERE id = $3`, disabledAt, now, userID)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		if _, err := tx.Exec(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// RevokeSessions ends all of the user's sessions
+func (r *UserRepository) RevokeSessions(userID int64) error {
+	_, err := r.DB.Exec(context.Background(), `DELETE FROM sessions WHERE user_id = $1`, userID)
+	return err
+}
+
+// DeleteUser removes a user. With transferTo set, their bookmarks and collections move to that user;
+// otherwise they are deleted together with their tags, highlights and history.
+func (r *UserRepository) DeleteUser(userID int64, transferTo *int64) error {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if transferTo != nil {
+		now := time.Now().UTC()
+		if _, err := tx.Exec(ctx, `UPDATE bookmarks SET user_id = $1, updated_at = $2 WHERE user_id = $3`, *transferTo, now, userID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE collections SET user_id = $1, updated_at = $2 WHERE user_id = $3`, *transferTo, now, userID); err != nil {
+			return err
+		}
+	} else {
+		if _, err := tx.Exec(ctx, `DELETE FROM bookmarks WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// SetTOTPSecret stores a new, not yet confirmed TOTP secret and turns two-factor authentication off until it is
+func (r *UserRepository) SetTOTPSecret(userID int64, secret string) error {
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE users SET totp_secret = $1, totp_enabled_at = NULL, totp_last_step = 0, updated_at = $2 WHERE id = $3`,
+		secret, time.Now().UTC(), userID)
+	return err
+}
+
+// EnableTOTP confirms the stored TOTP secret
+func (r *UserRepository) EnableTOTP(userID int64) error {
+	now := time.Now().UTC()
+	_, err := r.DB.Exec(context.Background(),
+		`UPDATE users SET totp_enabled_at = $1, updated_at = $1 WHERE id = $2 AND totp_secret IS NOT NULL`, now, userID)
+	return err
+}
+
+// UseTOTPStep records that a code for step was accepted. It reports false if that step or a later one
+// was already used, which makes accepting a code atomic under concurrent logins.
+func (r *UserRepository) UseTOTPStep(userID int64, step int64) (bool, error) {
+	tag, err := r.DB.Exec(context.Background(),
+		`UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1`, step, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DisableTOTP removes the TOTP secret, recovery codes and pending login challenges of a user
+func (r *UserRepository) DisableTOTP(userID int64) error {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = $1 WHERE id = $2`,
+		time.Now().UTC(), userID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM two_factor_challenges WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes for new ones, given as hashes
+func (r *UserRepository) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	createdAt := time.Now().UTC()
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`,
+			userID, hash, createdAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether there was one
+func (r *UserRepository) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	tag, err := r.DB.Exec(context.Background(),
+		`UPDATE user_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CreateTwoFactorChallenge stores a pending second login step, identified by the hash of its token
+func (r *UserRepository) CreateTwoFactorChallenge(userID int64, tokenHash string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(context.Background(),
+		`INSERT INTO two_factor_challenges (token_hash, user_id, attempts, expires_at, created_at) VALUES ($1, $2, 0, $3, $4)`,
+		tokenHash, userID, expiresAt, time.Now().UTC())
+	return err
+}
+
+// AttemptTwoFactorChallenge counts an attempt at a challenge and returns its user, expiry and attempt count
+func (r *UserRepository) AttemptTwoFactorChallenge(tokenHash string) (int64, time.Time, int, error) {
+	var userID int64
+	var expiresAt time.Time
+	var attempts int
+	err := r.DB.QueryRow(context.Background(),
+		`UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE token_hash = $1
+		 RETURNING user_id, expires_at, attempts`, tokenHash,
+	).Scan(&userID, &expiresAt, &attempts)
+	return userID, expiresAt, attempts, err
+}
+
+// DeleteTwoFactorChallenge removes a challenge once it is completed, expired or exhausted
+func (r *UserRepository) DeleteTwoFactorChallenge(tokenHash string) error {
+	_, err := r.DB.Exec(context.Background(), `DELETE FROM two_factor_challenges WHERE token_hash = $1`, tokenHash)
+	return err
+}
+
+// DeleteExpiredTwoFactorChallenges removes challenges that expired before now
+func (r *UserRepository) DeleteExpiredTwoFactorChallenges(now time.Time) (int64, error) {
+	tag, err := r.DB.Exec(context.Background(), `DELETE FROM two_factor_challenges WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
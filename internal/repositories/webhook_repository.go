@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"bookmarker/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrWebhookNotFound is returned for webhooks and deliveries that do not exist or belong to another user.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// WebhookRepository defines the interface for outgoing webhooks and their delivery outbox.
+type WebhookRepository interface {
+	CreateWebhook(webhook models.Webhook) (models.Webhook, error)
+	// ListWebhooks retrieves the user's webhooks without their secrets
+	ListWebhooks(userID int) ([]models.Webhook, error)
+	GetWebhook(userID int, id int) (models.Webhook, error)
+	// UpdateWebhook stores the URL, events and active flag of one of the user's webhooks
+	UpdateWebhook(webhook models.Webhook) (models.Webhook, error)
+	DeleteWebhook(userID int, id int) error
+	// EnqueueEvent queues the payload for every active webhook of the user subscribed to the event
+	EnqueueEvent(event string, userID int64, payload []byte) error
+	// EnqueueTagEvent queues the payload for the subscribed webhooks of every user with a bookmark
+	// tagged with the tag or one of its descendants
+	EnqueueTagEvent(event string, tagID int, payload []byte) error
+	// ClaimDueDeliveries picks pending deliveries that are due and postpones them by lease,
+	// so other dispatchers leave them alone while they are sent
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// SaveDeliveryAttempt stores the outcome of an attempt to send a delivery
+	SaveDeliveryAttempt(delivery models.WebhookDelivery) error
+	// ListDeliveries retrieves the latest deliveries of a webhook, newest first
+	ListDeliveries(webhookID int, limit int) ([]models.WebhookDelivery, error)
+	// RetryDelivery queues a delivery of the webhook to be sent again right away
+	RetryDelivery(webhookID int, deliveryID int64) (models.WebhookDelivery, error)
+	// DeleteFinishedDeliveriesBefore removes delivered and failed deliveries created before the given time
+	DeleteFinishedDeliveriesBefore(before time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db DBTX
+}
+
+const webhookColumns = `id, user_id, url, events, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event, d.payload::text, d.status, d.attempts, d.next_attempt_at,
+	d.last_attempt_at, d.response_status, d.last_error, d.created_at, d.delivered_at`
+
+func scanWebhook(row rowScanner) (models.Webhook, error) {
+	var w models.Webhook
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Events, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+	return w, err
+}
+
+func scanWebhookDelivery(row rowScanner, extra ...any) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	dest := append([]any{&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt}, extra...)
+	err := row.Scan(dest...)
+	return d, err
+}
+
+// CreateWebhook stores a new webhook; the returned webhook keeps the secret it was given
+func (r webhookRepository) CreateWebhook(webhook models.Webhook) (models.Webhook, error) {
+	ts := time.Now().UTC()
+	created, err := scanWebhook(r.db.QueryRow(context.Background(),
+		`INSERT INTO webhooks (user_id, url, secret, events, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $6) RETURNING `+webhookColumns,
+		webhook.UserID, webhook.URL, webhook.Secret, webhook.Events, webhook.Active, ts))
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	created.Secret = webhook.Secret
+	return created, nil
+}
+
+// ListWebhooks retrieves the user's webhooks without their secrets.
+func (r webhookRepository) ListWebhooks(userID int) ([]models.Webhook, error) {
+	rows, err := r.db.Query(context.Background(),
+		`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return webhooks, nil
+}
+
+func (r webhookRepository) GetWebhook(userID int, id int) (models.Webhook, error) {
+	w, err := scanWebhook(r.db.QueryRow(context.Background(),
+		`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Webhook{}, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+// UpdateWebhook stores the URL, events and active flag of one of the user's webhooks.
+func (r webhookRepository) UpdateWebhook(webhook models.Webhook) (models.Webhook, error) {
+	w, err := scanWebhook(r.db.QueryRow(context.Background(),
+		`UPDATE webhooks SET url = $3, events = $4, active = $5, updated_at = $6
+		 WHERE id = $1 AND user_id = $2 RETURNING `+webhookColumns,
+		webhook.ID, webhook.UserID, webhook.URL, webhook.Events, webhook.Active, time.Now().UTC()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Webhook{}, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (r webhookRepository) DeleteWebhook(userID int, id int) error {
+	tag, err := r.db.Exec(context.Background(), `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// EnqueueEvent queues the payload for every active webhook of the user subscribed to the event.
+// With a transaction as DBTX, the deliveries are only queued if the change they report is committed.
+func (r webhookRepository) EnqueueEvent(event string, userID int64, payload []byte) error {
+	ts := time.Now().UTC()
+	_, err := r.db.Exec(context.Background(),
+		`INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at, created_at)
+		 SELECT id, $1, $3::jsonb, $4, $5, $5 FROM webhooks
+		 WHERE active AND $1 = ANY(events) AND user_id = $2`,
+		event, userID, string(payload), models.WebhookDeliveryPending, ts)
+	return err
+}
+
+// EnqueueTagEvent queues the payload for the subscribed webhooks of the users whose bookmarks carry the tag
+// or one of its descendants, so users who never used a shared tag do not hear about it
+func (r webhookRepository) EnqueueTagEvent(event string, tagID int, payload []byte) error {
+	ts := time.Now().UTC()
+	_, err := r.db.Exec(context.Background(),
+		`WITH RECURSIVE subtree AS (
+			SELECT id FROM tags WHERE id = $2
+			UNION
+			SELECT t.id FROM tags t INNER JOIN subtree s ON t.parent_id = s.id
+		)
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at, created_at)
+		SELECT w.id, $1, $3::jsonb, $4, $5, $5 FROM webhooks w
+		WHERE w.active AND $1 = ANY(w.events) AND EXISTS (
+			SELECT 1 FROM bookmarks b INNER JOIN bookmarks_tags bt ON bt.bookmark_id = b.id
+			WHERE b.user_id = w.user_id AND bt.tag_id IN (SELECT id FROM subtree))`,
+		event, tagID, string(payload), models.WebhookDeliveryPending, ts)
+	return err
+}
+
+// ClaimDueDeliveries picks pending deliveries that are due, oldest first, and postpones them by lease.
+// Rows locked by another dispatcher are skipped.
+func (r webhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	rows, err := r.db.Query(context.Background(),
+		`UPDATE webhook_deliveries d SET next_attempt_at = $3
+		 FROM webhooks w
+		 WHERE w.id = d.webhook_id AND d.id IN (
+		     SELECT id FROM webhook_deliveries
+		     WHERE status = $1 AND next_attempt_at <= $2
+		     ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED)
+		 RETURNING `+webhookDeliveryColumns+`, w.url, w.secret`,
+		models.WebhookDeliveryPending, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		d.URL, d.Secret = url, secret
+		deliveries = append(deliveries, d)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return deliveries, nil
+}
+
+// SaveDeliveryAttempt stores the outcome of an attempt to send a delivery.
+func (r webhookRepository) SaveDeliveryAttempt(d models.WebhookDelivery) error {
+	_, err := r.db.Exec(context.Background(),
+		`UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+		 response_status = $6, last_error = $7, delivered_at = $8 WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastAttemptAt, d.ResponseStatus, d.LastError, d.DeliveredAt)
+	return err
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook, newest first.
+func (r webhookRepository) ListDeliveries(webhookID int, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(context.Background(),
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries d WHERE d.webhook_id = $1 ORDER BY d.id DESC LIMIT $2`,
+		webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery queues a delivery of the webhook to be sent again right away.
+func (r webhookRepository) RetryDelivery(webhookID int, deliveryID int64) (models.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRow(context.Background(),
+		`UPDATE webhook_deliveries d SET status = $3, next_attempt_at = $4, delivered_at = NULL
+		 WHERE d.id = $1 AND d.webhook_id = $2 RETURNING `+webhookDeliveryColumns,
+		deliveryID, webhookID, models.WebhookDeliveryPending, time.Now().UTC()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.WebhookDelivery{}, ErrWebhookNotFound
+	}
+	return d, err
+}
+
+// DeleteFinishedDeliveriesBefore removes delivered and failed deliveries created before the given time.
+func (r webhookRepository) DeleteFinishedDeliveriesBefore(before time.Time) (int64, error) {
+	tag, err := r.db.Exec(context.Background(),
+		`DELETE FROM webhook_deliveries WHERE status <> $1 AND created_at < $2`, models.WebhookDeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func NewWebhookRepository(db DBTX) WebhookRepository {
+	return &webhookRepository{db: db}
+}
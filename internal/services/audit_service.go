@@ -0,0 +1,22 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"log"
+)
+
+type AuditService struct {
+	AuditRepo *repositories.AuditRepository
+}
+
+func NewAuditService(auditRepo *repositories.AuditRepository) *AuditService {
+	return &AuditService{AuditRepo: auditRepo}
+}
+
+// Record writes an audit entry. Failing to audit must not fail the request, so errors are only logged.
+func (s *AuditService) Record(entry models.AuditEntry) {
+	if err := s.AuditRepo.CreateEntry(entry); err != nil {
+		log.Printf("Failed to write audit entry %s: %v", entry.Event, err)
+	}
+}
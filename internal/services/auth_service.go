@@ -1,139 +1,497 @@
 package services
 
 import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"os"
-	"sync"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Token lifetimes
+const (
+	accessTokenTTL  = 30 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// challengeTTL is how long the second login step may take
+	challengeTTL = 5 * time.Minute
+)
+
+// maxChallengeAttempts is how many codes may be tried against one login challenge
+const maxChallengeAttempts = 5
+
+// recoveryCodeCount is how many recovery codes are issued at a time
+const recoveryCodeCount = 10
+
+// ErrRefreshTokenReused is returned when an already exchanged refresh token is presented again.
+// Only a copy of the token can be reused, so the whole session is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused; session revoked")
+
+// ErrInvalidCredentials is returned for an unknown username or a wrong password; the two are not told apart.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// ErrAccountDisabled is returned when a disabled user presents correct credentials.
+var ErrAccountDisabled = errors.New("account disabled")
+
+// ErrInvalidTwoFactorCode is returned for a wrong, reused or expired TOTP or recovery code.
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// ErrInvalidChallenge is returned for an unknown, expired or exhausted two-factor login challenge.
+var ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+
+// ErrTwoFactorAlreadyEnabled is returned when starting enrollment while two-factor authentication is on.
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// ErrTwoFactorNotEnabled is returned when confirming or managing two-factor authentication that is not set up.
+var ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+// ErrSessionNotFound is returned when a session does not exist or belongs to another user.
+var ErrSessionNotFound = errors.New("session not found")
+
 type AuthService struct {
 	UserService          *UserService
 	TokenService         *TokenService
 	RefreshTokenService  *RefreshTokenService
+	SessionService       *SessionService
+	LoginThrottleService *LoginThrottleService
+	AuditService         *AuditService
 }
 
-func NewAuthService(userService *UserService, tokenService *TokenService, refreshTokenService *RefreshTokenService) *AuthService {
+func NewAuthService(userService *UserService, tokenService *TokenService, refreshTokenService *RefreshTokenService, sessionService *SessionService, loginThrottleService *LoginThrottleService, auditService *AuditService) *AuthService {
 	return &AuthService{
-		UserService:         userService,
-		TokenService:        tokenService,
-		RefreshTokenService: refreshTokenService,
+		UserService:          userService,
+		TokenService:         tokenService,
+		RefreshTokenService:  refreshTokenService,
+		SessionService:       sessionService,
+		LoginThrottleService: loginThrottleService,
+		AuditService:         auditService,
 	}
 }
 
-// AuthResult holds both access and refresh tokens
+// AuthResult holds both access and refresh tokens.
+// For users with two-factor authentication, Authenticate returns only a ChallengeToken
+// to be completed with CompleteTwoFactorLogin.
 type AuthResult struct {
-	AccessToken  string
-	RefreshToken string
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
+
+// SessionClient describes the device a login or refresh comes from
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
 }
 
-// Authenticate checks credentials, creates and stores both tokens, and returns them
-func (a *AuthService) Authenticate(username, password string) (*AuthResult, error) {
+// Authenticate checks credentials, starts a session for the client, and returns its tokens.
+// It returns ErrInvalidCredentials for a bad username or password and a *LoginThrottledError
+// while the username or the client's IP address is backing off after failed attempts.
+func (a *AuthService) Authenticate(username, password string, client SessionClient) (*AuthResult, error) {
+	if err := a.LoginThrottleService.Check(username, client.IPAddress); err != nil {
+		var throttled *LoginThrottledError
+		if errors.As(err, &throttled) {
+			a.audit(models.AuditLoginThrottled, nil, username, client, throttled.Error())
+		}
+		return nil, err
+	}
 	user, err := a.UserService.GetUserByUsername(username)
-	if (err != nil) {
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, a.loginFailed(nil, username, client, "unknown username")
+	}
+	if err != nil {
 		return nil, err
 	}
-	
 	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, a.loginFailed(&user.ID, username, client, "wrong password")
+	}
+	if user.DisabledAt != nil {
+		a.audit(models.AuditLoginFailed, &user.ID, username, client, "account disabled")
+		return nil, ErrAccountDisabled
+	}
+	// With two-factor authentication the failure count is only reset once the second step succeeds,
+	// so knowing the password does not buy unlimited guesses at the code
+	if user.TwoFactorEnabled {
+		challengeToken, err := generateRandomToken()
+		if err != nil {
+			return nil, err
+		}
+		err = a.UserService.UserRepo.CreateTwoFactorChallenge(user.ID, repositories.HashToken(challengeToken), time.Now().Add(challengeTTL))
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResult{ChallengeToken: challengeToken}, nil
+	}
+	a.loginSucceeded(user.Username)
+	return a.startSession(user, client)
+}
+
+// startSession creates a session for a fully authenticated user and issues its tokens
+func (a *AuthService) startSession(user models.User, client SessionClient) (*AuthResult, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	session, err := a.SessionService.CreateSession(int(user.ID), client.UserAgent, client.IPAddress)
+	if err != nil {
 		return nil, err
 	}
-	accessToken := generateRandomToken()
-	accessExpiresAt := time.Now().Add(30 * time.Minute) // 30 minutes expiry
-	if err := a.TokenService.CreateToken(int(user.ID), accessToken, accessExpiresAt); err != nil {
+	return a.issueTokens(int(user.ID), int(session.ID))
+}
+
+// StartExternalSession starts a session for a user authenticated by an external identity provider.
+// Local two-factor authentication is not asked for; the provider enforces its own.
+func (a *AuthService) StartExternalSession(user models.User, client SessionClient) (*AuthResult, error) {
+	if user.DisabledAt != nil {
+		a.audit(models.AuditLoginFailed, &user.ID, user.Username, client, "account disabled")
+		return nil, ErrAccountDisabled
+	}
+	return a.startSession(user, client)
+}
+
+// CompleteTwoFactorLogin finishes a login started by Authenticate with a TOTP or recovery code.
+// Wrong codes count as failed logins, so the second step is throttled like the first.
+func (a *AuthService) CompleteTwoFactorLogin(challengeToken, code string, client SessionClient) (*AuthResult, error) {
+	tokenHash := repositories.HashToken(challengeToken)
+	userID, expiresAt, attempts, err := a.UserService.UserRepo.AttemptTwoFactorChallenge(tokenHash)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	if time.Now().After(expiresAt) || attempts > maxChallengeAttempts {
+		if err := a.UserService.UserRepo.DeleteTwoFactorChallenge(tokenHash); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidChallenge
+	}
+	user, err := a.UserService.GetUserByID(userID)
+	if err != nil {
 		return nil, err
 	}
-	refreshToken := generateRandomToken()
-	refreshExpiresAt := time.Now().Add(30 * 24 * time.Hour) // 30 days
-	if err := a.RefreshTokenService.CreateRefreshToken(int(user.ID), refreshToken, refreshExpiresAt); err != nil {
+	if err := a.LoginThrottleService.Check(user.Username, client.IPAddress); err != nil {
+		return nil, err
+	}
+	ok, err := a.verifySecondFactor(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := a.loginFailed(&user.ID, user.Username, client, "wrong two-factor code"); !errors.Is(err, ErrInvalidCredentials) {
+			return nil, err
+		}
+		return nil, ErrInvalidTwoFactorCode
+	}
+	if err := a.UserService.UserRepo.DeleteTwoFactorChallenge(tokenHash); err != nil {
+		return nil, err
+	}
+	a.loginSucceeded(user.Username)
+	return a.startSession(user, client)
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code, consuming either
+func (a *AuthService) verifySecondFactor(user models.User, code string) (bool, error) {
+	if !user.TwoFactorEnabled || user.TOTPSecret == nil {
+		return false, ErrTwoFactorNotEnabled
+	}
+	code = strings.TrimSpace(code)
+	if step, ok := validateTOTP(*user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		return a.UserService.UserRepo.UseTOTPStep(user.ID, step)
+	}
+	return a.UserService.UserRepo.UseRecoveryCode(user.ID, repositories.HashToken(normalizeRecoveryCode(code)))
+}
+
+// BeginTOTPEnrollment generates a new TOTP secret for the user and returns it with its provisioning URI.
+// Two-factor authentication stays off until ConfirmTOTPEnrollment succeeds.
+func (a *AuthService) BeginTOTPEnrollment(userID int) (string, string, error) {
+	user, err := a.UserService.GetUserByID(int64(userID))
+	if err != nil {
+		return "", "", err
+	}
+	if user.TwoFactorEnabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := a.UserService.UserRepo.SetTOTPSecret(user.ID, secret); err != nil {
+		return "", "", err
+	}
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Bookmarker"
+	}
+	return secret, totpProvisioningURI(issuer, user.Username, secret), nil
+}
+
+// ConfirmTOTPEnrollment turns two-factor authentication on once the user proves their app produces
+// valid codes, and returns a fresh set of recovery codes
+func (a *AuthService) ConfirmTOTPEnrollment(userID int, code string) ([]string, error) {
+	user, err := a.UserService.GetUserByID(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	step, ok := validateTOTP(*user.TOTPSecret, strings.TrimSpace(code), time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	if _, err := a.UserService.UserRepo.UseTOTPStep(user.ID, step); err != nil {
+		return nil, err
+	}
+	if err := a.UserService.UserRepo.EnableTOTP(user.ID); err != nil {
+		return nil, err
+	}
+	return a.replaceRecoveryCodes(user.ID)
+}
+
+// DisableTOTP turns two-factor authentication off after checking a TOTP or recovery code
+func (a *AuthService) DisableTOTP(userID int, code string) error {
+	user, err := a.UserService.GetUserByID(int64(userID))
+	if err != nil {
+		return err
+	}
+	ok, err := a.verifySecondFactor(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return a.UserService.UserRepo.DisableTOTP(user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a TOTP or recovery code
+func (a *AuthService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	user, err := a.UserService.GetUserByID(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	ok, err := a.verifySecondFactor(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	return a.replaceRecoveryCodes(user.ID)
+}
+
+// replaceRecoveryCodes generates new single-use recovery codes, stores their hashes and returns them
+func (a *AuthService) replaceRecoveryCodes(userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		secret, err := generateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(secret[:10])
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, repositories.HashToken(raw))
+	}
+	if err := a.UserService.UserRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode strips the separator and spacing users may type around a recovery code
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// loginFailed counts and audits a failed login and returns ErrInvalidCredentials
+func (a *AuthService) loginFailed(userID *int64, username string, client SessionClient, reason string) error {
+	a.audit(models.AuditLoginFailed, userID, username, client, reason)
+	lockedOut, err := a.LoginThrottleService.RecordFailure(username, client.IPAddress)
+	if err != nil {
+		return err
+	}
+	if lockedOut {
+		a.audit(models.AuditLoginLockout, userID, username, client, "too many failed attempts")
+	}
+	return ErrInvalidCredentials
+}
+
+// loginSucceeded clears the username's failed login count once every login step has passed
+func (a *AuthService) loginSucceeded(username string) {
+	if err := a.LoginThrottleService.RecordSuccess(username); err != nil {
+		log.Printf("Failed to reset login throttle for %s: %v", username, err)
+	}
+}
+
+// audit records an authentication event when an audit service is configured
+func (a *AuthService) audit(event string, userID *int64, username string, client SessionClient, detail string) {
+	if a.AuditService == nil {
+		return
+	}
+	a.AuditService.Record(models.AuditEntry{
+		UserID:    userID,
+		Event:     event,
+		Username:  username,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Detail:    detail,
+	})
+}
+
+// issueTokens creates and stores a new access and refresh token pair for a session
+func (a *AuthService) issueTokens(userID int, sessionID int) (*AuthResult, error) {
+	accessToken, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.TokenService.CreateToken(userID, sessionID, accessToken, time.Now().Add(accessTokenTTL)); err != nil {
+		return nil, err
+	}
+	refreshToken, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.RefreshTokenService.CreateRefreshToken(userID, sessionID, refreshToken, time.Now().Add(refreshTokenTTL)); err != nil {
 		return nil, err
 	}
 	return &AuthResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
-// RefreshTokens validates a refresh token and issues new tokens
-func (a *AuthService) RefreshTokens(refreshToken string) (*AuthResult, error) {
+// RefreshTokens exchanges a refresh token for a new pair in the same session.
+// Used refresh tokens are kept until they expire so that presenting one again revokes the session.
+func (a *AuthService) RefreshTokens(refreshToken string, client SessionClient) (*AuthResult, error) {
 	t, err := a.RefreshTokenService.RefreshTokenRepo.FindByToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
+	if t.UsedAt != nil {
+		return nil, a.revokeReusedSession(t)
+	}
 	if time.Now().After(t.ExpiresAt) {
 		return nil, fmt.Errorf("refresh token expired")
 	}
-	// Invalidate old refresh token
-	err = a.RefreshTokenService.DeleteRefreshToken(refreshToken)
+	// Invalidate old refresh token; losing the race to a concurrent refresh counts as reuse
+	fresh, err := a.RefreshTokenService.RefreshTokenRepo.MarkUsed(refreshToken)
 	if err != nil {
 		return nil, err
 	}
-	// Issue new tokens
-	accessToken := generateRandomToken()
-	accessExpiresAt := time.Now().Add(30 * time.Minute) // 30 minutes expiry
-	if err := a.TokenService.CreateToken(int(t.UserID), accessToken, accessExpiresAt); err != nil {
-		return nil, err
+	if !fresh {
+		return nil, a.revokeReusedSession(t)
 	}
-	newRefreshToken := generateRandomToken()
-	refreshExpiresAt := time.Now().Add(30 * 24 * time.Hour)
-	if err := a.RefreshTokenService.CreateRefreshToken(int(t.UserID), newRefreshToken, refreshExpiresAt); err != nil {
-		return nil, err
+	if err := a.SessionService.SessionRepo.TouchSession(int(t.SessionID), client.IPAddress); err != nil {
+		log.Printf("Failed to update session %d: %v", t.SessionID, err)
 	}
-	return &AuthResult{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+	return a.issueTokens(int(t.UserID), int(t.SessionID))
 }
 
-// ValidateAccessToken checks if the token exists and is not expired, returning the user ID if valid
-func (a *AuthService) ValidateAccessToken(token string) (int, error) {
+// revokeReusedSession revokes the session of a refresh token that was presented twice
+func (a *AuthService) revokeReusedSession(t *models.RefreshToken) error {
+	log.Printf("Refresh token reuse detected for user %d, revoking session %d", t.UserID, t.SessionID)
+	if err := a.SessionService.DeleteSession(int(t.SessionID)); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// ValidateAccessToken checks if the token exists and is not expired, returning it so the caller
+// knows the user and session
+func (a *AuthService) ValidateAccessToken(token string, client SessionClient) (*models.Token, error) {
 	t, err := a.TokenService.TokenRepo.FindByToken(token)
 	if err != nil {
-		return 0, err // token not found or db error
+		return nil, err // token not found or db error
 	}
 	if time.Now().After(t.ExpiresAt) {
-		return 0, fmt.Errorf("token expired")
+		return nil, fmt.Errorf("token expired")
 	}
-	return int(t.UserID), nil
+	if err := a.SessionService.SessionRepo.TouchSession(int(t.SessionID), client.IPAddress); err != nil {
+		log.Printf("Failed to update session %d: %v", t.SessionID, err)
+	}
+	return t, nil
 }
 
-// Logout deletes both access and refresh tokens for a user
+// Logout revokes the session the given tokens belong to, including any other tokens issued to it
 func (a *AuthService) Logout(accessToken, refreshToken string) error {
-	// Delete access token
-	err1 := a.TokenService.DeleteToken(accessToken)
-	// Delete refresh token
-	err2 := a.RefreshTokenService.DeleteRefreshToken(refreshToken)
-	if err1 != nil {
-		return err1
+	if t, err := a.TokenService.FindByToken(accessToken); err == nil {
+		return a.SessionService.DeleteSession(int(t.SessionID))
 	}
-	if err2 != nil {
-		return err2
+	if t, err := a.RefreshTokenService.FindByToken(refreshToken); err == nil {
+		return a.SessionService.DeleteSession(int(t.SessionID))
 	}
 	return nil
 }
 
-// generateRandomToken generates a random string for use as a token
-func generateRandomToken() string {
-	return RandString(48)
+// ListSessions retrieves the user's sessions, marking the one with ID currentSessionID
+func (a *AuthService) ListSessions(userID int, currentSessionID int) ([]models.Session, error) {
+	sessions, err := a.SessionService.ListSessions(userID)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == int64(currentSessionID)
+	}
+	return sessions, err
 }
 
-// RandString returns a random alphanumeric string of given length
-func RandString(n int) string {
-	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letters[seededRand.Intn(len(letters))]
+// RevokeSession logs one of the user's sessions out
+func (a *AuthService) RevokeSession(userID int, sessionID int) error {
+	session, err := a.SessionService.SessionRepo.GetSessionByID(sessionID)
+	if err != nil || session.UserID != int64(userID) {
+		return ErrSessionNotFound
 	}
-	return string(b)
+	return a.SessionService.DeleteSession(sessionID)
 }
 
+// RevokeAllSessions logs the user out everywhere except keepSessionID (0 keeps none) and returns how many sessions ended
+func (a *AuthService) RevokeAllSessions(userID int, keepSessionID int) (int64, error) {
+	return a.SessionService.SessionRepo.DeleteUserSessions(userID, keepSessionID)
+}
 
-func dd(v interface{}) {
-	fmt.Printf("%#v\n", v)
-	os.Exit(1)
+// ChangePassword replaces the user's password after checking the current one and logs out
+// every session except keepSessionID
+func (a *AuthService) ChangePassword(userID int, keepSessionID int, currentPassword, newPassword string) error {
+	user, err := a.UserService.GetUserByID(int64(userID))
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)) != nil {
+		return ErrInvalidCredentials
+	}
+	if err := a.UserService.SetPassword(user.ID, newPassword); err != nil {
+		return err
+	}
+	_, err = a.SessionService.SessionRepo.DeleteUserSessions(userID, keepSessionID)
+	return err
 }
 
-var seededRand *rand.Rand
-var once sync.Once
+// PurgeExpiredTokens deletes expired access and refresh tokens, the sessions left without any,
+// and expired two-factor login challenges
+func (a *AuthService) PurgeExpiredTokens() (int64, error) {
+	now := time.Now()
+	accessCount, err := a.TokenService.TokenRepo.DeleteExpired(now)
+	if err != nil {
+		return 0, err
+	}
+	refreshCount, err := a.RefreshTokenService.RefreshTokenRepo.DeleteExpired(now)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := a.SessionService.SessionRepo.DeleteAbandoned(now.Add(-time.Hour)); err != nil {
+		return 0, err
+	}
+	if _, err := a.UserService.UserRepo.DeleteExpiredTwoFactorChallenges(now); err != nil {
+		return 0, err
+	}
+	return accessCount + refreshCount, nil
+}
 
-func init() {
-	once.Do(func() {
-		seededRand = rand.New(rand.NewSource(time.Now().UnixNano()))
-	})
+// generateRandomToken returns a token with 256 bits from crypto/rand
+func generateRandomToken() (string, error) {
+	return generateSecureToken(32)
+}
+
+func dd(v interface{}) {
+	fmt.Printf("%#v\n", v)
+	os.Exit(1)
 }
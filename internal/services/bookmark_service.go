@@ -4,56 +4,214 @@ import (
 	"bookmarker/internal/clients"
 	"bookmarker/internal/models"
 	"bookmarker/internal/repositories"
+	"context"
+	"errors"
+	"sort"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrBookmarkNotFound is returned when a bookmark does not exist or is not visible to the user.
+var ErrBookmarkNotFound = errors.New("bookmark not found")
+
+// ErrInvalidVisibility is returned for visibility values other than private, instance or public.
+var ErrInvalidVisibility = errors.New("visibility must be private, instance or public")
+
+// ErrInvalidReadState is returned for reading states other than unread, reading, read or archived.
+var ErrInvalidReadState = errors.New("read state must be unread, reading, read or archived")
+
+// ErrRevisionNotFound is returned when a revision does not belong to the bookmark.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// BookmarkInput holds the fields used to create a bookmark.
+// Empty Title, Description and Thumbnail are filled from the URL preview.
+type BookmarkInput struct {
+	URL         string
+	Title       string
+	Description string
+	Thumbnail   string
+	Tags        []string
+	CreatedAt   time.Time
+	// UserID is the owner of the bookmark; bookmarks without one are hidden until assign-bookmarks gives them an owner
+	UserID *int64
+	// Visibility defaults to private
+	Visibility string
+	// ReadState queues the bookmark for reading; empty keeps it as a reference
+	ReadState string
+	// Notes is the user's Markdown annotation, kept apart from the fetched description
+	Notes string
+}
+
 // BookmarkService defines the service layer interface.
 type BookmarkService interface {
-	CreateBookmarkWithTags(url, title, description, thumbnail string, tags []string, createdAt time.Time) (models.Bookmark, error)
+	CreateBookmarkWithTags(input BookmarkInput) (models.Bookmark, error)
 	GetBookmarkByID(id int) (models.Bookmark, error)
 	GetBookmarkWithTags(id int) (models.Bookmark, error)
-	ListBookmarks(page int, pageSize int) ([]models.Bookmark, error)
-	ListBookmarksByTag(tagID int, page int, pageSize int) ([]models.Bookmark, error)
-	ListBookmarksWithTags(page int, pageSize int) ([]models.Bookmark, error)
-	UpdateBookmark(id int, fields map[string]interface{}) (models.Bookmark, error)
-	UpdateBookmarkWithTags(id int, fields map[string]interface{}, tags []string) (models.Bookmark, error)
-	// SearchBookmarks performs a paginated text search on title, url, or description
-	SearchBookmarks(query string, page int, pageSize int) ([]models.Bookmark, error)
-	DeleteBookmark(id int) error // Add this method
+	// GetVisibleBookmarkWithTags fetches a bookmark with tags if the user is allowed to see it
+	GetVisibleBookmarkWithTags(userID int, id int) (models.Bookmark, error)
+	// EnsureCanEdit returns ErrBookmarkNotFound unless the user owns the bookmark
+	EnsureCanEdit(userID int, id int) error
+	ListBookmarks(filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error)
+	ListBookmarksByTag(tagID int, filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error)
+	ListBookmarksWithTags(filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error)
+	// ListSharedWithInstance retrieves other users' bookmarks shared with the instance, including tags
+	ListSharedWithInstance(userID int, page int, pageSize int) ([]models.Bookmark, error)
+	// UpdateBookmark changes the given fields and records a revision attributed to editorID
+	UpdateBookmark(editorID int, id int, fields map[string]interface{}) (models.Bookmark, error)
+	// UpdateBookmarkWithTags changes the given fields and replaces the tags, recording a revision attributed to editorID
+	UpdateBookmarkWithTags(editorID int, id int, fields map[string]interface{}, tags []string) (models.Bookmark, error)
+	// ListRevisions retrieves the edit history of a bookmark, newest first
+	ListRevisions(id int) ([]models.BookmarkRevision, error)
+	// RevertToRevision puts a bookmark back the way it was before the given revision
+	RevertToRevision(editorID int, id int, revisionID int) (models.Bookmark, error)
+	// SearchBookmarks performs a paginated text search on title, url, description, notes and highlights
+	SearchBookmarks(query string, filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error)
+	// DeleteBookmark moves a bookmark to the trash
+	DeleteBookmark(id int) error
+	// ListTrash retrieves the user's bookmarks in the trash, including tags
+	ListTrash(userID int, page int, pageSize int) ([]models.Bookmark, error)
+	// RestoreBookmark takes one of the user's bookmarks out of the trash
+	RestoreBookmark(userID int, id int) (models.Bookmark, error)
+	// PurgeTrash permanently deletes bookmarks that have been in the trash longer than retention
+	PurgeTrash(retention time.Duration) (int64, error)
+	// AssignUnownedBookmarks gives every bookmark without an owner to the user
+	AssignUnownedBookmarks(userID int) (int64, error)
+	// SetReadState moves a bookmark through the read-later queue; an empty state removes it from the queue
+	SetReadState(id int, state string) (models.Bookmark, error)
+	// GetNextUnread returns the oldest unread bookmark in the user's queue, including tags
+	GetNextUnread(userID int) (models.Bookmark, error)
+	// ListTagCounts retrieves the most used tags among the bookmarks matching the filter
+	ListTagCounts(filter repositories.BookmarkFilter, limit int) ([]models.TagCount, error)
+}
+
+// ViewerFilter returns the filter for a user's own listings
+func ViewerFilter(userID int) repositories.BookmarkFilter {
+	return repositories.BookmarkFilter{ViewerID: userID}
 }
 
 // bookmarkService implementation of the BookmarkService interface.
 type bookmarkService struct {
-	repo    repositories.BookmarkRepository
-	tagRepo repositories.TagRepository
+	db           *pgxpool.Pool
+	repo         repositories.BookmarkRepository
+	tagRepo      repositories.TagRepository
+	revisionRepo repositories.RevisionRepository
+	webhookRepo  repositories.WebhookRepository
+	tagRules     TagNormalizationRules
 }
 
 // NewBookmarkService creates a new instance of the bookmarkService.
 func NewBookmarkService(repo repositories.BookmarkRepository) BookmarkService {
 	return &bookmarkService{
-		repo: repo,
+		repo:     repo,
+		tagRules: TagNormalizationRulesFromEnv(),
 	}
 }
 
 // NewBookmarkServiceWithTags creates a new instance of the bookmarkService with tagRepo.
 func NewBookmarkServiceWithTags(repo repositories.BookmarkRepository, tagRepo repositories.TagRepository) BookmarkService {
 	return &bookmarkService{
-		repo:    repo,
-		tagRepo: tagRepo,
+		repo:     repo,
+		tagRepo:  tagRepo,
+		tagRules: TagNormalizationRulesFromEnv(),
 	}
 }
 
-// CreateBookmarkWithTags creates a bookmark and associates tags.
-func (s *bookmarkService) CreateBookmarkWithTags(url, title, description, thumbnail string, tags []string, createdAt time.Time) (models.Bookmark, error) {
+// NewBookmarkServiceWithHistory creates a new instance of the bookmarkService that records a revision on every update.
+func NewBookmarkServiceWithHistory(repo repositories.BookmarkRepository, tagRepo repositories.TagRepository, revisionRepo repositories.RevisionRepository) BookmarkService {
+	return &bookmarkService{
+		repo:         repo,
+		tagRepo:      tagRepo,
+		revisionRepo: revisionRepo,
+		tagRules:     TagNormalizationRulesFromEnv(),
+	}
+}
 
-	// Deduplicate tags
-	tagSet := make(map[string]struct{})
-	for _, tag := range tags {
-		tagSet[tag] = struct{}{}
+// NewBookmarkServiceWithWebhooks creates a new instance of the bookmarkService that records a revision on every
+// update and queues webhook deliveries for every bookmark it creates, updates or deletes. Each change is written
+// in one transaction together with its revision and deliveries.
+func NewBookmarkServiceWithWebhooks(db *pgxpool.Pool) BookmarkService {
+	return &bookmarkService{
+		db:           db,
+		repo:         repositories.NewBookmarkRepository(db),
+		tagRepo:      repositories.NewTagRepository(db),
+		revisionRepo: repositories.NewRevisionRepository(db),
+		webhookRepo:  repositories.NewWebhookRepository(db),
+		tagRules:     TagNormalizationRulesFromEnv(),
+	}
+}
+
+// inTransaction runs change with a copy of the service bound to a transaction, so the revisions and webhook
+// deliveries it writes are committed together with the change or not at all. A service without a pool,
+// such as the one bulkService builds inside its own transaction, runs change directly.
+func (s *bookmarkService) inTransaction(change func(tx *bookmarkService) error) error {
+	if s.db == nil {
+		return change(s)
+	}
+	ctx := context.Background()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	err = change(&bookmarkService{
+		repo:         repositories.NewBookmarkRepository(tx),
+		tagRepo:      repositories.NewTagRepository(tx),
+		revisionRepo: repositories.NewRevisionRepository(tx),
+		webhookRepo:  repositories.NewWebhookRepository(tx),
+		tagRules:     s.tagRules,
+	})
+	if err != nil {
+		return err
 	}
-	uniqueTags := make([]string, 0, len(tagSet))
-	for tag := range tagSet {
-		uniqueTags = append(uniqueTags, tag)
+	return tx.Commit(ctx)
+}
+
+// prepareTags normalizes tag names, resolves aliases to their canonical tags and removes duplicates
+func (s *bookmarkService) prepareTags(tags []string) ([]string, error) {
+	normalized := s.tagRules.NormalizeAll(tags)
+	aliases, err := s.tagRepo.ResolveTagAliases(normalized)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range normalized {
+		if canonical, ok := aliases[name]; ok {
+			normalized[i] = canonical
+		}
+	}
+	// Aliases may collapse several names onto the same tag
+	seen := make(map[string]struct{})
+	uniqueTags := make([]string, 0, len(normalized))
+	for _, name := range normalized {
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			uniqueTags = append(uniqueTags, name)
+		}
+	}
+	return uniqueTags, nil
+}
+
+// CreateBookmarkWithTags creates a bookmark and associates tags.
+func (s *bookmarkService) CreateBookmarkWithTags(input BookmarkInput) (models.Bookmark, error) {
+	url, title, description, thumbnail := input.URL, input.Title, input.Description, input.Thumbnail
+	if input.Visibility == "" {
+		input.Visibility = models.VisibilityPrivate
+	}
+	if !models.IsValidVisibility(input.Visibility) {
+		return models.Bookmark{}, ErrInvalidVisibility
+	}
+	var readState *string
+	if input.ReadState != "" {
+		if !models.IsValidReadState(input.ReadState) {
+			return models.Bookmark{}, ErrInvalidReadState
+		}
+		readState = &input.ReadState
+	}
+
+	// Normalize, resolve aliases and deduplicate tags
+	uniqueTags, err := s.prepareTags(input.Tags)
+	if err != nil {
+		return models.Bookmark{}, err
 	}
 
 	// Fetch URL preview if needed
@@ -86,14 +244,38 @@ func (s *bookmarkService) CreateBookmarkWithTags(url, title, description, thumbn
 		thumbnail = "/placeholders/site5.png"
 	}
 
-	// Create the bookmark
-	bookmark, err := s.repo.CreateBookmark(url, title, description, thumbnail, createdAt)
+	var notes *string
+	if input.Notes != "" {
+		notes = &input.Notes
+	}
+
+	var bookmark models.Bookmark
+	err = s.inTransaction(func(tx *bookmarkService) (err error) {
+		bookmark, err = tx.createBookmark(models.Bookmark{
+			UserID:      input.UserID,
+			URL:         url,
+			Title:       title,
+			Description: &description,
+			Thumbnail:   &thumbnail,
+			Visibility:  input.Visibility,
+			Notes:       notes,
+			ReadState:   readState,
+			CreatedAt:   input.CreatedAt,
+		}, uniqueTags)
+		return err
+	})
+	return bookmark, err
+}
+
+// createBookmark stores a bookmark with its already prepared tags and queues bookmark.created
+func (s *bookmarkService) createBookmark(input models.Bookmark, tags []string) (models.Bookmark, error) {
+	bookmark, err := s.repo.CreateBookmark(input)
 	if err != nil {
 		return bookmark, err
 	}
 
 	// Use new repo method to get/create tags and associate
-	tagStructs, err := s.tagRepo.GetAndCreateTagsIfMissing(uniqueTags)
+	tagStructs, err := s.tagRepo.GetAndCreateTagsIfMissing(tags)
 	if err != nil {
 		return bookmark, err
 	}
@@ -113,7 +295,7 @@ func (s *bookmarkService) CreateBookmarkWithTags(url, title, description, thumbn
 		})
 	}
 
-	return bookmark, nil
+	return bookmark, s.queueBookmarkEvent(models.WebhookEventBookmarkCreated, bookmark)
 }
 
 // GetBookmarkByID fetches a bookmark by its ID.
@@ -138,24 +320,73 @@ func (s *bookmarkService) GetBookmarkWithTags(id int) (models.Bookmark, error) {
 	return bookmark, nil
 }
 
+// GetVisibleBookmarkWithTags fetches a bookmark with tags if the user owns it or another user shares it
+// with the instance or public.
+func (s *bookmarkService) GetVisibleBookmarkWithTags(userID int, id int) (models.Bookmark, error) {
+	bookmark, err := s.GetBookmarkWithTags(id)
+	if err != nil {
+		return models.Bookmark{}, ErrBookmarkNotFound
+	}
+	if !ownsBookmark(userID, bookmark) {
+		if bookmark.UserID == nil || bookmark.Visibility == models.VisibilityPrivate {
+			return models.Bookmark{}, ErrBookmarkNotFound
+		}
+		// Notes stay personal even when the bookmark itself is shared
+		bookmark.Notes = nil
+	}
+	return bookmark, nil
+}
+
+// EnsureCanEdit returns ErrBookmarkNotFound unless the user owns the bookmark.
+func (s *bookmarkService) EnsureCanEdit(userID int, id int) error {
+	bookmark, err := s.repo.GetBookmarkByID(id)
+	if err != nil || !ownsBookmark(userID, bookmark) {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// ownsBookmark reports whether the user owns the bookmark; nobody owns a bookmark without an owner
+func ownsBookmark(userID int, bookmark models.Bookmark) bool {
+	return bookmark.UserID != nil && *bookmark.UserID == int64(userID)
+}
+
 // ListBookmarks retrieves paginated bookmarks.
-func (s *bookmarkService) ListBookmarks(page int, pageSize int) ([]models.Bookmark, error) {
+func (s *bookmarkService) ListBookmarks(filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error) {
 	offset := (page - 1) * pageSize
-	return s.repo.ListBookmarks(offset, pageSize)
+	return s.repo.ListBookmarks(filter, offset, pageSize)
 }
 
 // ListBookmarksByTag retrieves paginated bookmarks associated with a tag ID.
-func (s *bookmarkService) ListBookmarksByTag(tagID int, page int, pageSize int) ([]models.Bookmark, error) {
+func (s *bookmarkService) ListBookmarksByTag(tagID int, filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error) {
 	offset := (page - 1) * pageSize
-	return s.repo.ListBookmarksByTag(tagID, offset, pageSize)
+	return s.repo.ListBookmarksByTag(tagID, filter, offset, pageSize)
 }
 
 // ListBookmarksWithTags retrieves paginated bookmarks and includes tags.
-func (s *bookmarkService) ListBookmarksWithTags(page int, pageSize int) ([]models.Bookmark, error) {
-	bookmarks, err := s.ListBookmarks(page, pageSize)
+func (s *bookmarkService) ListBookmarksWithTags(filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error) {
+	bookmarks, err := s.ListBookmarks(filter, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
+	return s.attachTags(bookmarks)
+}
+
+// ListSharedWithInstance retrieves other users' bookmarks shared with the instance or public, including tags.
+// Notes stay personal and are removed.
+func (s *bookmarkService) ListSharedWithInstance(userID int, page int, pageSize int) ([]models.Bookmark, error) {
+	bookmarks, err := s.ListBookmarksWithTags(repositories.BookmarkFilter{
+		ExcludeOwnerID: userID,
+		Visibilities:   []string{models.VisibilityInstance, models.VisibilityPublic},
+	}, page, pageSize)
+	for i := range bookmarks {
+		bookmarks[i].Notes = nil
+	}
+	return bookmarks, err
+}
+
+// attachTags sets the tags of each bookmark
+func (s *bookmarkService) attachTags(bookmarks []models.Bookmark) ([]models.Bookmark, error) {
 	if s.tagRepo == nil {
 		return bookmarks, nil
 	}
@@ -170,27 +401,65 @@ func (s *bookmarkService) ListBookmarksWithTags(page int, pageSize int) ([]model
 }
 
 // PatchBookmark updates only the provided fields of a bookmark.
-func (s *bookmarkService) UpdateBookmark(id int, fields map[string]interface{}) (models.Bookmark, error) {
-	return s.repo.UpdateBookmark(id, fields)
+func (s *bookmarkService) UpdateBookmark(editorID int, id int, fields map[string]interface{}) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	err := s.inTransaction(func(tx *bookmarkService) (err error) {
+		bookmark, err = tx.updateBookmark(editorID, id, fields)
+		return err
+	})
+	return bookmark, err
 }
 
-func (s *bookmarkService) UpdateBookmarkWithTags(id int, fields map[string]interface{}, tags []string) (models.Bookmark, error) {
+func (s *bookmarkService) updateBookmark(editorID int, id int, fields map[string]interface{}) (models.Bookmark, error) {
+	before, err := s.revisionSnapshot(id)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
 	bookmark, err := s.repo.UpdateBookmark(id, fields)
 	if err != nil {
 		return bookmark, err
 	}
-	if s.tagRepo == nil {
-		return bookmark, nil
+	if s.tagRepo != nil {
+		bookmark.Tags, err = s.tagRepo.GetTagsForBookmark(id)
+		if err != nil {
+			return bookmark, err
+		}
+	}
+	if err := s.recordRevision(editorID, before, bookmark); err != nil {
+		return bookmark, err
 	}
+	return bookmark, s.queueBookmarkEvent(models.WebhookEventBookmarkUpdated, bookmark)
+}
 
-	// Deduplicate tags
-	tagSet := make(map[string]struct{})
-	for _, tag := range tags {
-		tagSet[tag] = struct{}{}
+func (s *bookmarkService) UpdateBookmarkWithTags(editorID int, id int, fields map[string]interface{}, tags []string) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	err := s.inTransaction(func(tx *bookmarkService) (err error) {
+		bookmark, err = tx.updateBookmarkWithTags(editorID, id, fields, tags)
+		return err
+	})
+	return bookmark, err
+}
+
+func (s *bookmarkService) updateBookmarkWithTags(editorID int, id int, fields map[string]interface{}, tags []string) (models.Bookmark, error) {
+	before, err := s.revisionSnapshot(id)
+	if err != nil {
+		return models.Bookmark{}, err
 	}
-	uniqueTags := make([]string, 0, len(tagSet))
-	for tag := range tagSet {
-		uniqueTags = append(uniqueTags, tag)
+	bookmark, err := s.repo.UpdateBookmark(id, fields)
+	if err != nil {
+		return bookmark, err
+	}
+	if s.tagRepo == nil {
+		if err := s.recordRevision(editorID, before, bookmark); err != nil {
+			return bookmark, err
+		}
+		return bookmark, s.queueBookmarkEvent(models.WebhookEventBookmarkUpdated, bookmark)
+	}
+
+	// Normalize, resolve aliases and deduplicate tags
+	uniqueTags, err := s.prepareTags(tags)
+	if err != nil {
+		return bookmark, err
 	}
 	// Get or create tags
 	tagStructs, err := s.tagRepo.GetAndCreateTagsIfMissing(uniqueTags)
@@ -223,16 +492,243 @@ func (s *bookmarkService) UpdateBookmarkWithTags(id int, fields map[string]inter
 	if err != nil {
 		return bookmark, err
 	}
-	return bookmark, nil
+	if err := s.recordRevision(editorID, before, bookmark); err != nil {
+		return bookmark, err
+	}
+	return bookmark, s.queueBookmarkEvent(models.WebhookEventBookmarkUpdated, bookmark)
+}
+
+// revisionFields are the bookmark columns tracked in the edit history.
+// The reading state is left out: it has its own timestamps and is not worth reverting.
+var revisionFields = []string{"url", "title", "description", "thumbnail", "visibility", "notes"}
+
+// isRevisionField reports whether field is a tracked column, so stored history never names arbitrary columns
+func isRevisionField(field string) bool {
+	for _, f := range revisionFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// revisionFieldValue returns the value of a tracked field; nil means the field is empty
+func revisionFieldValue(bookmark models.Bookmark, field string) *string {
+	switch field {
+	case "url":
+		return &bookmark.URL
+	case "title":
+		return &bookmark.Title
+	case "description":
+		return bookmark.Description
+	case "thumbnail":
+		return bookmark.Thumbnail
+	case "visibility":
+		return &bookmark.Visibility
+	case "notes":
+		return bookmark.Notes
+	}
+	return nil
+}
+
+// revisionSnapshot fetches the bookmark as it is before an update, when history is recorded
+func (s *bookmarkService) revisionSnapshot(id int) (models.Bookmark, error) {
+	if s.revisionRepo == nil {
+		return models.Bookmark{}, nil
+	}
+	return s.GetBookmarkWithTags(id)
+}
+
+// recordRevision stores what changed between before and after; edits that change nothing are not recorded
+func (s *bookmarkService) recordRevision(editorID int, before models.Bookmark, after models.Bookmark) error {
+	if s.revisionRepo == nil {
+		return nil
+	}
+	revision := models.BookmarkRevision{
+		BookmarkID: after.ID,
+		Changes:    []models.RevisionChange{},
+	}
+	if editorID != 0 {
+		editor := int64(editorID)
+		revision.UserID = &editor
+	}
+	for _, field := range revisionFields {
+		from, to := revisionFieldValue(before, field), revisionFieldValue(after, field)
+		if (from == nil) != (to == nil) || derefString(from) != derefString(to) {
+			revision.Changes = append(revision.Changes, models.RevisionChange{Field: field, From: from, To: to})
+		}
+	}
+	fromTags, toTags := sortedTagNames(before.Tags), sortedTagNames(after.Tags)
+	if !equalStrings(fromTags, toTags) {
+		revision.Tags = &models.TagChange{From: fromTags, To: toTags}
+	}
+	if len(revision.Changes) == 0 && revision.Tags == nil {
+		return nil
+	}
+	_, err := s.revisionRepo.CreateRevision(revision)
+	return err
 }
 
-// SearchBookmarks performs a paginated text search on title, url, or description
-func (s *bookmarkService) SearchBookmarks(query string, page int, pageSize int) ([]models.Bookmark, error) {
+// sortedTagNames returns the tag names in alphabetical order, never nil
+func sortedTagNames(tags []models.BookmarkTag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ListRevisions retrieves the edit history of a bookmark, newest first.
+func (s *bookmarkService) ListRevisions(id int) ([]models.BookmarkRevision, error) {
+	if s.revisionRepo == nil {
+		return nil, nil
+	}
+	return s.revisionRepo.ListRevisions(id)
+}
+
+// RevertToRevision puts a bookmark back the way it was before the given revision by undoing it
+// and every later revision, newest first. The revert is itself recorded as a new revision.
+func (s *bookmarkService) RevertToRevision(editorID int, id int, revisionID int) (models.Bookmark, error) {
+	if s.revisionRepo == nil {
+		return models.Bookmark{}, ErrRevisionNotFound
+	}
+	revisions, err := s.revisionRepo.ListRevisionsSince(id, revisionID)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
+	if len(revisions) == 0 || revisions[len(revisions)-1].ID != int64(revisionID) {
+		return models.Bookmark{}, ErrRevisionNotFound
+	}
+
+	fields := make(map[string]interface{})
+	var tags []string
+	for _, revision := range revisions {
+		for _, change := range revision.Changes {
+			if isRevisionField(change.Field) {
+				fields[change.Field] = change.From
+			}
+		}
+		if revision.Tags != nil {
+			tags = revision.Tags.From
+		}
+	}
+	if tags != nil {
+		return s.UpdateBookmarkWithTags(editorID, id, fields, tags)
+	}
+	return s.UpdateBookmark(editorID, id, fields)
+}
+
+// SearchBookmarks performs a paginated text search on title, url, description, notes and highlights
+func (s *bookmarkService) SearchBookmarks(query string, filter repositories.BookmarkFilter, page int, pageSize int) ([]models.Bookmark, error) {
 	offset := (page - 1) * pageSize
-	return s.repo.SearchBookmarks(query, offset, pageSize)
+	return s.repo.SearchBookmarks(query, filter, offset, pageSize)
 }
 
-// DeleteBookmark removes a bookmark by its ID.
+// DeleteBookmark moves a bookmark to the trash.
 func (s *bookmarkService) DeleteBookmark(id int) error {
-	return s.repo.DeleteBookmark(id)
+	if s.webhookRepo == nil {
+		return s.repo.DeleteBookmark(id)
+	}
+	return s.inTransaction(func(tx *bookmarkService) error {
+		// The event carries the bookmark as it was before it went to the trash
+		bookmark, err := tx.GetBookmarkWithTags(id)
+		if err != nil {
+			return err
+		}
+		if err := tx.repo.DeleteBookmark(id); err != nil {
+			return err
+		}
+		return tx.queueBookmarkEvent(models.WebhookEventBookmarkDeleted, bookmark)
+	})
+}
+
+// SetReadState moves a bookmark through the read-later queue; an empty state removes it from the queue.
+func (s *bookmarkService) SetReadState(id int, state string) (models.Bookmark, error) {
+	if state != "" && !models.IsValidReadState(state) {
+		return models.Bookmark{}, ErrInvalidReadState
+	}
+	var bookmark models.Bookmark
+	err := s.inTransaction(func(tx *bookmarkService) (err error) {
+		bookmark, err = tx.repo.SetReadState(id, state)
+		if err != nil {
+			return err
+		}
+		if tx.tagRepo != nil {
+			bookmark.Tags, err = tx.tagRepo.GetTagsForBookmark(id)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.queueBookmarkEvent(models.WebhookEventBookmarkUpdated, bookmark)
+	})
+	return bookmark, err
+}
+
+// GetNextUnread returns the oldest unread bookmark in the user's queue, including tags.
+func (s *bookmarkService) GetNextUnread(userID int) (models.Bookmark, error) {
+	bookmark, err := s.repo.GetNextUnread(ViewerFilter(userID))
+	if err != nil {
+		return models.Bookmark{}, ErrBookmarkNotFound
+	}
+	if s.tagRepo != nil {
+		bookmark.Tags, err = s.tagRepo.GetTagsForBookmark(int(bookmark.ID))
+	}
+	return bookmark, err
+}
+
+// ListTrash retrieves the user's bookmarks in the trash, including tags.
+func (s *bookmarkService) ListTrash(userID int, page int, pageSize int) ([]models.Bookmark, error) {
+	filter := ViewerFilter(userID)
+	filter.Trashed = true
+	return s.ListBookmarksWithTags(filter, page, pageSize)
+}
+
+// RestoreBookmark takes one of the user's bookmarks out of the trash with its tags intact.
+func (s *bookmarkService) RestoreBookmark(userID int, id int) (models.Bookmark, error) {
+	bookmark, err := s.repo.GetTrashedBookmarkByID(id)
+	if err != nil || !ownsBookmark(userID, bookmark) {
+		return models.Bookmark{}, ErrBookmarkNotFound
+	}
+	err = s.inTransaction(func(tx *bookmarkService) error {
+		if _, err := tx.repo.RestoreBookmark(id); err != nil {
+			return err
+		}
+		bookmark, err = tx.GetBookmarkWithTags(id)
+		if err != nil {
+			return err
+		}
+		return tx.queueBookmarkEvent(models.WebhookEventBookmarkUpdated, bookmark)
+	})
+	return bookmark, err
+}
+
+// PurgeTrash permanently deletes bookmarks that have been in the trash longer than retention.
+func (s *bookmarkService) PurgeTrash(retention time.Duration) (int64, error) {
+	return s.repo.PurgeTrashedBefore(time.Now().Add(-retention))
+}
+
+// AssignUnownedBookmarks gives every bookmark without an owner to the user.
+func (s *bookmarkService) AssignUnownedBookmarks(userID int) (int64, error) {
+	return s.repo.AssignUnownedBookmarks(userID)
+}
+
+// ListTagCounts retrieves the most used tags among the bookmarks matching the filter, most used first.
+func (s *bookmarkService) ListTagCounts(filter repositories.BookmarkFilter, limit int) ([]models.TagCount, error) {
+	if s.tagRepo == nil {
+		return nil, nil
+	}
+	return s.tagRepo.ListTagCounts(filter, limit)
 }
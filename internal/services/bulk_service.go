@@ -0,0 +1,263 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Bulk operations on bookmarks
+const (
+	BulkAddTags          = "add_tags"
+	BulkRemoveTags       = "remove_tags"
+	BulkReplaceTags      = "replace_tags"
+	BulkDelete           = "delete"
+	BulkSetReadState     = "set_read_state"
+	BulkMoveToCollection = "move_to_collection"
+)
+
+// Statuses of a single bookmark in a bulk result
+const (
+	BulkItemUpdated   = "updated"
+	BulkItemUnchanged = "unchanged"
+	BulkItemFailed    = "failed"
+	// BulkItemSkipped is reported for bookmarks left untouched after an earlier one failed
+	BulkItemSkipped = "skipped"
+)
+
+// MaxBulkItems caps how many bookmarks a single bulk request may touch
+const MaxBulkItems = 1000
+
+// ErrInvalidBulkOperation is returned for operations other than the Bulk* constants.
+var ErrInvalidBulkOperation = errors.New("operation must be add_tags, remove_tags, replace_tags, delete, set_read_state or move_to_collection")
+
+// ErrBulkSelection is returned when a bulk request names neither bookmark IDs nor a search query.
+var ErrBulkSelection = errors.New("either ids or query is required")
+
+// ErrTooManyBulkItems is returned when a bulk request selects more than MaxBulkItems bookmarks.
+var ErrTooManyBulkItems = errors.New("too many bookmarks selected")
+
+// BulkRequest selects bookmarks by ID or by search query and describes the operation to apply to them
+type BulkRequest struct {
+	IDs       []int
+	Query     string
+	Operation string
+	// Tags is used by the tag operations
+	Tags []string
+	// ReadState is used by set_read_state; empty removes the bookmarks from the queue
+	ReadState string
+	// CollectionID is used by move_to_collection
+	CollectionID int
+	// DryRun runs the operation and rolls it back, reporting what would change
+	DryRun bool
+}
+
+// BulkItemResult is the outcome of a bulk operation for one bookmark
+type BulkItemResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResult reports the outcome of a bulk operation. Applied is false for dry runs and failed runs.
+type BulkResult struct {
+	Operation string           `json:"operation"`
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	Matched   int              `json:"matched"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// BulkService applies one operation to many bookmarks in a single transaction.
+type BulkService interface {
+	// Run applies the request for the user; if any bookmark fails, nothing is applied
+	Run(userID int, req BulkRequest) (BulkResult, error)
+}
+
+type bulkService struct {
+	db *pgxpool.Pool
+}
+
+// NewBulkService creates a new instance of the bulkService.
+func NewBulkService(db *pgxpool.Pool) BulkService {
+	return &bulkService{db: db}
+}
+
+// isValidBulkOperation reports whether op is one of the Bulk* operations
+func isValidBulkOperation(op string) bool {
+	switch op {
+	case BulkAddTags, BulkRemoveTags, BulkReplaceTags, BulkDelete, BulkSetReadState, BulkMoveToCollection:
+		return true
+	}
+	return false
+}
+
+// Run applies the request for the user in one transaction. Every bookmark goes through the same service
+// code as single edits, so tags are normalized and revisions are recorded. The first failure stops the
+// run and rolls everything back; dry runs are always rolled back.
+func (s *bulkService) Run(userID int, req BulkRequest) (BulkResult, error) {
+	if !isValidBulkOperation(req.Operation) {
+		return BulkResult{}, ErrInvalidBulkOperation
+	}
+	if req.Operation == BulkSetReadState && req.ReadState != "" && !models.IsValidReadState(req.ReadState) {
+		return BulkResult{}, ErrInvalidReadState
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	bookmarks := &bookmarkService{
+		repo:         repositories.NewBookmarkRepository(tx),
+		tagRepo:      repositories.NewTagRepository(tx),
+		revisionRepo: repositories.NewRevisionRepository(tx),
+		webhookRepo:  repositories.NewWebhookRepository(tx),
+		tagRules:     TagNormalizationRulesFromEnv(),
+	}
+	collections := repositories.NewCollectionRepository(tx)
+
+	ids, err := s.selectBookmarks(bookmarks, userID, req)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	if req.Operation == BulkMoveToCollection {
+		collection, err := collections.GetCollectionByID(req.CollectionID)
+		if err != nil || collection.UserID != int64(userID) {
+			return BulkResult{}, ErrCollectionNotFound
+		}
+	}
+	// Removal names are compared with stored tags, so they get the same normalization and alias resolution
+	var removeTags map[string]struct{}
+	if req.Operation == BulkRemoveTags {
+		names, err := bookmarks.prepareTags(req.Tags)
+		if err != nil {
+			return BulkResult{}, err
+		}
+		removeTags = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			removeTags[name] = struct{}{}
+		}
+	}
+
+	result := BulkResult{
+		Operation: req.Operation,
+		DryRun:    req.DryRun,
+		Matched:   len(ids),
+		Items:     make([]BulkItemResult, 0, len(ids)),
+	}
+	for _, id := range ids {
+		item := BulkItemResult{ID: id, Status: BulkItemSkipped}
+		if result.Failed == 0 {
+			changed, err := applyBulkOperation(bookmarks, collections, userID, id, req, removeTags)
+			switch {
+			case err != nil:
+				item.Status = BulkItemFailed
+				item.Error = err.Error()
+				result.Failed++
+			case changed:
+				item.Status = BulkItemUpdated
+				result.Updated++
+			default:
+				item.Status = BulkItemUnchanged
+				result.Unchanged++
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if req.DryRun || result.Failed > 0 {
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return BulkResult{}, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// selectBookmarks resolves the request to bookmark IDs, removing duplicates
+func (s *bulkService) selectBookmarks(bookmarks *bookmarkService, userID int, req BulkRequest) ([]int, error) {
+	var ids []int
+	switch {
+	case len(req.IDs) > 0:
+		seen := make(map[int]struct{}, len(req.IDs))
+		for _, id := range req.IDs {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	case req.Query != "":
+		// Fetch one more than allowed to tell a full page from an oversized selection
+		matches, err := bookmarks.repo.SearchBookmarks(req.Query, ViewerFilter(userID), 0, MaxBulkItems+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, bookmark := range matches {
+			ids = append(ids, int(bookmark.ID))
+		}
+	default:
+		return nil, ErrBulkSelection
+	}
+	if len(ids) > MaxBulkItems {
+		return nil, ErrTooManyBulkItems
+	}
+	return ids, nil
+}
+
+// applyBulkOperation applies the request to one bookmark and reports whether anything changed
+func applyBulkOperation(bookmarks *bookmarkService, collections repositories.CollectionRepository, userID int, id int, req BulkRequest, removeTags map[string]struct{}) (bool, error) {
+	if err := bookmarks.EnsureCanEdit(userID, id); err != nil {
+		return false, err
+	}
+	before, err := bookmarks.GetBookmarkWithTags(id)
+	if err != nil {
+		return false, err
+	}
+	beforeTags := sortedTagNames(before.Tags)
+
+	switch req.Operation {
+	case BulkAddTags, BulkRemoveTags, BulkReplaceTags:
+		var tags []string
+		switch req.Operation {
+		case BulkAddTags:
+			tags = append(append(tags, beforeTags...), req.Tags...)
+		case BulkRemoveTags:
+			for _, name := range beforeTags {
+				if _, ok := removeTags[name]; !ok {
+					tags = append(tags, name)
+				}
+			}
+		default:
+			tags = req.Tags
+		}
+		after, err := bookmarks.UpdateBookmarkWithTags(userID, id, map[string]interface{}{}, tags)
+		if err != nil {
+			return false, err
+		}
+		return !equalStrings(beforeTags, sortedTagNames(after.Tags)), nil
+	case BulkDelete:
+		return true, bookmarks.DeleteBookmark(id)
+	case BulkSetReadState:
+		if derefString(before.ReadState) == req.ReadState {
+			return false, nil
+		}
+		_, err := bookmarks.SetReadState(id, req.ReadState)
+		return err == nil, err
+	case BulkMoveToCollection:
+		if err := collections.RemoveBookmarkFromOtherCollections(userID, id, req.CollectionID); err != nil {
+			return false, err
+		}
+		return true, collections.AddBookmarkToCollection(req.CollectionID, id)
+	}
+	return false, ErrInvalidBulkOperation
+}
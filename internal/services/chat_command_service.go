@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChatCommand is a slash command from Slack or Discord. Name is the command without the slash,
+// "bookmark" or "bookmarks"; Text is everything after it.
+type ChatCommand struct {
+	Provider  string
+	AccountID string
+	Name      string
+	Text      string
+}
+
+// ChatCommandService answers /bookmark and /bookmarks from Slack and Discord. Replies are formatted in
+// the provider's markdown flavour and meant to be shown only to the user who ran the command.
+//
+//	/bookmark <url> [tags…]      save a link; words after it are tags, with or without #
+//	/bookmarks [recent]          latest bookmarks
+//	/bookmarks search <query>    find bookmarks
+//	/bookmarks link <code>       link the account with a code from POST /link-codes for this provider
+//	/bookmarks unlink            stop acting for the linked user
+type ChatCommandService struct {
+	Bookmarks BookmarkService
+	Accounts  *repositories.ChatAccountRepository
+}
+
+func NewChatCommandService(bookmarks BookmarkService, accounts *repositories.ChatAccountRepository) *ChatCommandService {
+	return &ChatCommandService{Bookmarks: bookmarks, Accounts: accounts}
+}
+
+func (s *ChatCommandService) ListAccounts(userID int) ([]models.ChatAccount, error) {
+	return s.Accounts.ListAccounts(userID)
+}
+
+func (s *ChatCommandService) UnlinkAccount(userID int, provider string, accountID string) error {
+	return s.Accounts.UnlinkAccount(userID, provider, accountID)
+}
+
+// Handle runs a command and returns the reply
+func (s *ChatCommandService) Handle(cmd ChatCommand) (string, error) {
+	f := chatFormat(cmd.Provider)
+	subcommand, args := parseChatSubcommand(cmd.Text)
+	if cmd.Name == "bookmarks" && subcommand == "link" {
+		return s.link(cmd, args)
+	}
+	userID, err := s.Accounts.GetAccountUserID(cmd.Provider, cmd.AccountID)
+	if errors.Is(err, repositories.ErrChatAccountNotLinked) {
+		return "This account is not linked to bookmarker yet. Create a " + cmd.Provider + " link code in bookmarker (POST /link-codes) and run " +
+			f.code("/bookmarks link <code>") + ".", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if cmd.Name == "bookmark" {
+		return s.save(f, userID, cmd.Text)
+	}
+	switch subcommand {
+	case "", "recent":
+		bookmarks, err := s.Bookmarks.ListBookmarksWithTags(ViewerFilter(int(userID)), 1, telegramResultLimit)
+		if err != nil {
+			return "", err
+		}
+		return f.bookmarks("Recent bookmarks", bookmarks, "No bookmarks yet."), nil
+	case "search":
+		if args == "" {
+			return "Usage: " + f.code("/bookmarks search <query>"), nil
+		}
+		bookmarks, err := s.Bookmarks.SearchBookmarks(args, ViewerFilter(int(userID)), 1, telegramResultLimit)
+		if err != nil {
+			return "", err
+		}
+		return f.bookmarks("Results for “"+f.escape(args)+"”", bookmarks, "Nothing found."), nil
+	case "unlink":
+		if err := s.Accounts.UnlinkAccount(int(userID), cmd.Provider, cmd.AccountID); err != nil {
+			return "", err
+		}
+		return "This account is no longer linked. Run " + f.code("/bookmarks link <code>") + " to link it again.", nil
+	default:
+		return chatHelp(f), nil
+	}
+}
+
+func (s *ChatCommandService) link(cmd ChatCommand, code string) (string, error) {
+	f := chatFormat(cmd.Provider)
+	if code == "" {
+		return "Usage: " + f.code("/bookmarks link <code>"), nil
+	}
+	_, err := s.Accounts.LinkAccount(repositories.HashToken(normalizeLinkCode(code)), cmd.Provider, cmd.AccountID)
+	if errors.Is(err, repositories.ErrLinkCodeNotFound) {
+		return "That code is invalid, has expired or was not created for " + cmd.Provider + ". Create a new one in bookmarker and try again.", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "This account is now linked to your bookmarker account. Save links with " + f.code("/bookmark <url> [tags]") + ".", nil
+}
+
+// save reads "/bookmark <url> tags…" with the same parser as Telegram messages; the words it would keep
+// as a note are tags here
+func (s *ChatCommandService) save(f chatFormatter, userID int64, text string) (string, error) {
+	save := ParseLinkText(text)
+	if len(save.URLs) == 0 {
+		return "Usage: " + f.code("/bookmark <url> [tags]"), nil
+	}
+	save.Tags = append(save.Tags, strings.FieldsFunc(save.Note, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})...)
+	save.Note = ""
+	bookmarks, err := saveLinks(s.Bookmarks, userID, save, defaultReadState("CHAT_DEFAULT_READ_STATE"))
+	if err != nil {
+		return "", err
+	}
+	return f.bookmarks("Saved", bookmarks, ""), nil
+}
+
+// parseChatSubcommand splits "search go generics" into the lowercased first word and the rest
+func parseChatSubcommand(text string) (string, string) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(text), " ")
+	return strings.ToLower(first), strings.TrimSpace(rest)
+}
+
+func chatHelp(f chatFormatter) string {
+	return strings.Join([]string{
+		f.code("/bookmark <url> [tags]") + " — save a link",
+		f.code("/bookmarks recent") + " — latest bookmarks",
+		f.code("/bookmarks search <query>") + " — find bookmarks",
+		f.code("/bookmarks unlink") + " — stop saving from this account",
+	}, "\n")
+}
+
+// chatFormatter renders replies in Slack's mrkdwn or Discord's markdown
+type chatFormatter struct {
+	slack bool
+}
+
+func chatFormat(provider string) chatFormatter {
+	return chatFormatter{slack: provider == models.ChatProviderSlack}
+}
+
+func (f chatFormatter) escape(text string) string {
+	if f.slack {
+		return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	}
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, "[", `\[`, "]", `\]`, ">", `\>`).Replace(text)
+}
+
+func (f chatFormatter) bold(text string) string {
+	if f.slack {
+		return "*" + text + "*"
+	}
+	return "**" + text + "**"
+}
+
+func (f chatFormatter) code(text string) string {
+	return "`" + text + "`"
+}
+
+func (f chatFormatter) link(url string, title string) string {
+	if f.slack {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E", "|", "%7C").Replace(url) + "|" + f.escape(title) + ">"
+	}
+	return "[" + f.escape(title) + "](<" + strings.ReplaceAll(url, ">", "%3E") + ">)"
+}
+
+// bookmarks renders a titled list of bookmarks, or empty when there are none
+func (f chatFormatter) bookmarks(title string, bookmarks []models.Bookmark, empty string) string {
+	if len(bookmarks) == 0 {
+		return empty
+	}
+	lines := []string{f.bold(title)}
+	for _, bookmark := range bookmarks {
+		lines = append(lines, f.bookmark(bookmark))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// bookmark renders one bookmark as its ID, a link and its tags, like formatTelegramBookmark
+func (f chatFormatter) bookmark(bookmark models.Bookmark) string {
+	title := strings.TrimSpace(bookmark.Title)
+	if title == "" {
+		title = bookmark.URL
+	}
+	if utf8.RuneCountInString(title) > telegramTitleLength {
+		title = string([]rune(title)[:telegramTitleLength-1]) + "…"
+	}
+	line := fmt.Sprintf("#%d %s", bookmark.ID, f.link(bookmark.URL, title))
+	if len(bookmark.Tags) > 0 {
+		line += " — " + f.escape(strings.Join(sortedTagNames(bookmark.Tags), ", "))
+	}
+	return line
+}
+
+// DiscordCommandText flattens the options of a Discord command into the text a Slack command would carry,
+// so "/bookmarks search query:go" reads as "search go"
+func DiscordCommandText(options []models.DiscordCommandOption) string {
+	var words []string
+	for _, option := range options {
+		if option.Type == 1 || option.Type == 2 {
+			words = append(words, option.Name, DiscordCommandText(option.Options))
+		} else if option.Value != nil {
+			words = append(words, fmt.Sprint(option.Value))
+		}
+	}
+	return strings.TrimSpace(strings.Join(words, " "))
+}
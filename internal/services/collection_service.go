@@ -0,0 +1,131 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"errors"
+)
+
+// ErrCollectionNotFound is returned when a collection does not exist or belongs to another user.
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// CollectionService defines the service layer interface for collections.
+type CollectionService interface {
+	CreateCollection(userID int, name, description string) (models.Collection, error)
+	GetCollection(userID int, id int) (models.Collection, error)
+	ListCollections(userID int) ([]models.Collection, error)
+	UpdateCollection(userID int, id int, fields map[string]interface{}) (models.Collection, error)
+	DeleteCollection(userID int, id int) error
+	AddBookmark(userID int, collectionID int, bookmarkID int) error
+	RemoveBookmark(userID int, collectionID int, bookmarkID int) error
+	ReorderBookmarks(userID int, collectionID int, bookmarkIDs []int) error
+	// ListBookmarksWithTags retrieves a collection's bookmarks in their manual order, including tags
+	ListBookmarksWithTags(userID int, collectionID int, page int, pageSize int) ([]models.Bookmark, error)
+}
+
+// collectionService implementation of the CollectionService interface.
+type collectionService struct {
+	repo         repositories.CollectionRepository
+	bookmarkRepo repositories.BookmarkRepository
+	tagRepo      repositories.TagRepository
+}
+
+// NewCollectionService creates a new instance of the collectionService.
+func NewCollectionService(repo repositories.CollectionRepository, bookmarkRepo repositories.BookmarkRepository, tagRepo repositories.TagRepository) CollectionService {
+	return &collectionService{
+		repo:         repo,
+		bookmarkRepo: bookmarkRepo,
+		tagRepo:      tagRepo,
+	}
+}
+
+// CreateCollection creates an empty collection owned by the user.
+func (s *collectionService) CreateCollection(userID int, name, description string) (models.Collection, error) {
+	if name == "" {
+		return models.Collection{}, errors.New("collection name required")
+	}
+	return s.repo.CreateCollection(userID, name, description)
+}
+
+// GetCollection fetches a collection, making sure it belongs to the user.
+func (s *collectionService) GetCollection(userID int, id int) (models.Collection, error) {
+	collection, err := s.repo.GetCollectionByID(id)
+	if err != nil || collection.UserID != int64(userID) {
+		return models.Collection{}, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+// ListCollections retrieves all collections owned by the user.
+func (s *collectionService) ListCollections(userID int) ([]models.Collection, error) {
+	return s.repo.ListCollections(userID)
+}
+
+// UpdateCollection updates only the provided fields of a collection.
+func (s *collectionService) UpdateCollection(userID int, id int, fields map[string]interface{}) (models.Collection, error) {
+	if _, err := s.GetCollection(userID, id); err != nil {
+		return models.Collection{}, err
+	}
+	return s.repo.UpdateCollection(id, fields)
+}
+
+// DeleteCollection removes a collection. The bookmarks themselves are kept.
+func (s *collectionService) DeleteCollection(userID int, id int) error {
+	if _, err := s.GetCollection(userID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteCollection(id)
+}
+
+// AddBookmark appends a bookmark to the end of a collection.
+func (s *collectionService) AddBookmark(userID int, collectionID int, bookmarkID int) error {
+	if _, err := s.GetCollection(userID, collectionID); err != nil {
+		return err
+	}
+	bookmark, err := s.bookmarkRepo.GetBookmarkByID(bookmarkID)
+	if err != nil {
+		return ErrBookmarkNotFound
+	}
+	if !ownsBookmark(userID, bookmark) && (bookmark.UserID == nil || bookmark.Visibility == models.VisibilityPrivate) {
+		return ErrBookmarkNotFound
+	}
+	return s.repo.AddBookmarkToCollection(collectionID, bookmarkID)
+}
+
+// RemoveBookmark removes a bookmark from a collection.
+func (s *collectionService) RemoveBookmark(userID int, collectionID int, bookmarkID int) error {
+	if _, err := s.GetCollection(userID, collectionID); err != nil {
+		return err
+	}
+	return s.repo.RemoveBookmarkFromCollection(collectionID, bookmarkID)
+}
+
+// ReorderBookmarks puts the listed bookmarks first, in the given order.
+func (s *collectionService) ReorderBookmarks(userID int, collectionID int, bookmarkIDs []int) error {
+	if _, err := s.GetCollection(userID, collectionID); err != nil {
+		return err
+	}
+	return s.repo.ReorderCollection(collectionID, bookmarkIDs)
+}
+
+// ListBookmarksWithTags retrieves a collection's bookmarks in their manual order, including tags.
+func (s *collectionService) ListBookmarksWithTags(userID int, collectionID int, page int, pageSize int) ([]models.Bookmark, error) {
+	if _, err := s.GetCollection(userID, collectionID); err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * pageSize
+	// Other users' bookmarks stay listed only while they are still shared
+	filter := repositories.BookmarkFilter{ViewerID: userID, IncludeShared: true}
+	bookmarks, err := s.repo.ListBookmarksInCollection(collectionID, filter, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bookmarks {
+		tags, err := s.tagRepo.GetTagsForBookmark(int(bookmarks[i].ID))
+		if err != nil {
+			return nil, err
+		}
+		bookmarks[i].Tags = tags
+	}
+	return bookmarks, nil
+}
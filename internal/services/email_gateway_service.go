@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// EmailMaxMessageBytes is the largest message the email gateway accepts, over SMTP or HTTP
+const EmailMaxMessageBytes = 10 << 20
+
+// emailConfirmationTTL is how long the code mailed to a newly added address can confirm it
+const emailConfirmationTTL = 24 * time.Hour
+
+// ErrUnknownSender is returned for mail from an address no active user registered.
+var ErrUnknownSender = errors.New("sender address is not registered")
+
+// ErrInvalidEmailAddress is returned when registering something that is not an email address.
+var ErrInvalidEmailAddress = errors.New("invalid email address")
+
+// EmailSaveResult reports what the gateway did with a message
+type EmailSaveResult struct {
+	Status    string            `json:"status"`
+	Bookmarks []models.Bookmark `json:"bookmarks,omitempty"`
+}
+
+// EmailGatewayService saves the links in mail sent from confirmed addresses as bookmarks.
+// Senders are identified by the From header, which mail servers do not authenticate on their own;
+// the gateway should only receive mail through a server that rejects forged senders (SPF, DKIM, DMARC).
+type EmailGatewayService struct {
+	Bookmarks BookmarkService
+	EmailRepo *repositories.EmailRepository
+	Mailer    *Mailer
+}
+
+func NewEmailGatewayService(bookmarks BookmarkService, emailRepo *repositories.EmailRepository) *EmailGatewayService {
+	return &EmailGatewayService{Bookmarks: bookmarks, EmailRepo: emailRepo, Mailer: NewMailerFromEnv()}
+}
+
+// AddAddress registers an unconfirmed sender address for the user and mails it a confirmation code.
+// Mail from the address is rejected until the user confirms it with ConfirmAddress.
+func (s *EmailGatewayService) AddAddress(userID int, address string) (models.EmailAddress, error) {
+	normalized, err := normalizeEmailAddress(address)
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	if !s.Mailer.Enabled() {
+		return models.EmailAddress{}, ErrMailerDisabled
+	}
+	code, err := generateSecureToken(16)
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	added, err := s.EmailRepo.AddAddress(userID, normalized, repositories.HashToken(code), time.Now().Add(emailConfirmationTTL))
+	if err != nil {
+		return models.EmailAddress{}, err
+	}
+	body := "Someone asked to save bookmarks to their Bookmarker account by mailing them from this address.\n\n" +
+		"If it was you, confirm the address with this code within 24 hours:\n\n" + code + "\n\n" +
+		"Otherwise you can ignore this message.\n"
+	if err := s.Mailer.Send(normalized, "Confirm your email address", body); err != nil {
+		return models.EmailAddress{}, err
+	}
+	return added, nil
+}
+
+// ConfirmAddress confirms one of the user's addresses with the code mailed to it
+func (s *EmailGatewayService) ConfirmAddress(userID int, code string) (models.EmailAddress, error) {
+	return s.EmailRepo.ConfirmAddress(userID, repositories.HashToken(strings.TrimSpace(code)), time.Now())
+}
+
+func (s *EmailGatewayService) ListAddresses(userID int) ([]models.EmailAddress, error) {
+	return s.EmailRepo.ListAddresses(userID)
+}
+
+func (s *EmailGatewayService) DeleteAddress(userID int, id int64) error {
+	return s.EmailRepo.DeleteAddress(userID, id)
+}
+
+// SaveEmail parses a raw message and saves one bookmark per link for the user who registered the sender.
+// envelopeFrom, the SMTP MAIL FROM address, is used when the message has no From header.
+func (s *EmailGatewayService) SaveEmail(envelopeFrom string, r io.Reader) (EmailSaveResult, error) {
+	save, err := ParseEmail(r)
+	if err != nil {
+		return EmailSaveResult{}, err
+	}
+	sender := save.From
+	if sender == "" {
+		sender = envelopeFrom
+	}
+	address, err := normalizeEmailAddress(sender)
+	if err != nil {
+		return EmailSaveResult{}, ErrUnknownSender
+	}
+	userID, err := s.EmailRepo.GetAddressUserID(address)
+	if errors.Is(err, repositories.ErrEmailAddressNotFound) {
+		return EmailSaveResult{}, ErrUnknownSender
+	}
+	if err != nil {
+		return EmailSaveResult{}, err
+	}
+	if len(save.URLs) == 0 {
+		return EmailSaveResult{Status: "no valid url detected"}, nil
+	}
+	log.Printf("[EmailGateway] URLs detected from %s: %q (tags: %v)", address, save.URLs, save.Tags)
+
+	bookmarks, err := saveLinks(s.Bookmarks, userID, save.LinkSave, defaultReadState("EMAIL_DEFAULT_READ_STATE"))
+	if err != nil {
+		return EmailSaveResult{}, err
+	}
+	return EmailSaveResult{Status: "bookmarks saved", Bookmarks: bookmarks}, nil
+}
+
+// HandleSMTP is the SMTPHandler of the gateway's SMTP server. Mail that can never be saved is rejected
+// permanently, so the sender gets a bounce; other failures ask the sending server to retry.
+func (s *EmailGatewayService) HandleSMTP(from string, to []string, data []byte) error {
+	result, err := s.SaveEmail(from, bytes.NewReader(data))
+	switch {
+	case errors.Is(err, ErrUnknownSender):
+		return &SMTPError{Code: 550, Message: "Sender address is not registered"}
+	case errors.Is(err, ErrInvalidEmail):
+		return &SMTPError{Code: 554, Message: "Message could not be parsed"}
+	case err != nil:
+		return err
+	}
+	log.Printf("[EmailGateway] Message from %q: %s (%d bookmarks)", from, result.Status, len(result.Bookmarks))
+	return nil
+}
+
+// normalizeEmailAddress accepts "Name <user@example.com>" or a bare address and returns it in lowercase
+func normalizeEmailAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return "", ErrInvalidEmailAddress
+	}
+	return strings.ToLower(parsed.Address), nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// emailMaxLinks caps the links saved from one message, as newsletters can link to dozens of pages
+const emailMaxLinks = 20
+
+// emailMaxPartDepth limits how deeply nested multipart and forwarded messages are read
+const emailMaxPartDepth = 8
+
+// ErrInvalidEmail is returned for messages that cannot be parsed as RFC 5322.
+var ErrInvalidEmail = errors.New("invalid email message")
+
+// emailTextURLPattern finds links in plain text; trailing punctuation is trimmed afterwards
+var emailTextURLPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// emailHrefPattern finds the targets of links in HTML bodies
+var emailHrefPattern = regexp.MustCompile(`(?is)<a\s[^>]*?href\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+// emailSubjectPrefixPattern matches the reply and forward markers mail clients put before a subject
+var emailSubjectPrefixPattern = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|aw|wg|tr)\s*:\s*)+`)
+
+// EmailSave is what a message asks to save: the links in its bodies, the #hashtags of its subject
+// as tags and the rest of the subject as a note
+type EmailSave struct {
+	From string
+	LinkSave
+}
+
+// ParseEmail reads a raw RFC 5322 message. Links are collected from text/plain and text/html parts,
+// including those of messages forwarded as attachments; other attachments are skipped.
+func ParseEmail(r io.Reader) (EmailSave, error) {
+	message, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return EmailSave{}, ErrInvalidEmail
+	}
+	var save EmailSave
+	if from, err := mail.ParseAddress(message.Header.Get("From")); err == nil {
+		save.From = from.Address
+	}
+	decoder := mime.WordDecoder{}
+	subject, err := decoder.DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		subject = message.Header.Get("Subject")
+	}
+	save.Tags, save.Note = parseEmailSubject(subject)
+
+	seen := map[string]bool{}
+	err = collectEmailURLs(textproto.MIMEHeader(message.Header), message.Body, 0, func(link string) {
+		if len(save.URLs) < emailMaxLinks {
+			save.URLs = appendUnique(save.URLs, seen, link)
+		}
+	})
+	if err != nil {
+		return EmailSave{}, err
+	}
+	return save, nil
+}
+
+// parseEmailSubject splits a subject into #hashtags and the remaining text, without Re:/Fwd: markers
+func parseEmailSubject(subject string) ([]string, string) {
+	subject = emailSubjectPrefixPattern.ReplaceAllString(subject, "")
+	var tags, words []string
+	for _, word := range strings.Fields(subject) {
+		if strings.HasPrefix(word, "#") && len(word) > 1 {
+			tags = append(tags, word[1:])
+		} else {
+			words = append(words, word)
+		}
+	}
+	return tags, strings.Join(words, " ")
+}
+
+// collectEmailURLs walks one MIME part and passes every link in its text to add
+func collectEmailURLs(header textproto.MIMEHeader, body io.Reader, depth int, add func(string)) error {
+	if depth > emailMaxPartDepth {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" && mediaType != "message/rfc822" {
+		return nil
+	}
+	body = decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return ErrInvalidEmail
+			}
+			if err := collectEmailURLs(part.Header, part, depth+1, add); err != nil {
+				return err
+			}
+		}
+	case mediaType == "message/rfc822":
+		forwarded, err := mail.ReadMessage(bufio.NewReader(body))
+		if err != nil {
+			return nil
+		}
+		return collectEmailURLs(textproto.MIMEHeader(forwarded.Header), forwarded.Body, depth+1, add)
+	case mediaType == "text/html":
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return ErrInvalidEmail
+		}
+		for _, match := range emailHrefPattern.FindAllStringSubmatch(string(content), -1) {
+			if link := cleanEmailURL(html.UnescapeString(match[1] + match[2] + match[3])); link != "" {
+				add(link)
+			}
+		}
+	case mediaType == "text/plain":
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return ErrInvalidEmail
+		}
+		for _, match := range emailTextURLPattern.FindAllString(string(content), -1) {
+			if link := cleanEmailURL(match); link != "" {
+				add(link)
+			}
+		}
+	}
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// cleanEmailURL trims punctuation that ends a sentence rather than the link and drops unsubscribe links
+func cleanEmailURL(link string) string {
+	link = strings.TrimSpace(link)
+	link = strings.TrimRight(link, ".,;:!?*")
+	if strings.HasSuffix(link, ")") && !strings.Contains(link, "(") {
+		link = strings.TrimSuffix(link, ")")
+	}
+	if !isValidURL(link) || strings.Contains(strings.ToLower(link), "unsubscribe") {
+		return ""
+	}
+	return link
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// exportPageSize is the page size used when collecting bookmarks for an export
+const exportPageSize = 200
+
+// ExportService writes bookmarks in formats other tools can import:
+// Pinboard-compatible JSON (the same format PinboardImportService reads)
+// and the Netscape bookmark HTML format understood by browsers.
+// Notes and highlights are included so an export is a complete backup of the user's annotations.
+type ExportService struct {
+	HighlightRepo repositories.HighlightRepository
+}
+
+func NewExportService(highlightRepo repositories.HighlightRepository) *ExportService {
+	return &ExportService{HighlightRepo: highlightRepo}
+}
+
+// exportedBookmark extends the Pinboard format with the user's annotations
+type exportedBookmark struct {
+	PinboardBookmark
+	Notes      string             `json:"notes,omitempty"`
+	Highlights []models.Highlight `json:"highlights,omitempty"`
+}
+
+// CollectBookmarks pages through fetch until it returns a short page and returns every bookmark seen, with highlights
+func (s *ExportService) CollectBookmarks(fetch func(page int, pageSize int) ([]models.Bookmark, error)) ([]models.Bookmark, error) {
+	var all []models.Bookmark
+	for page := 1; ; page++ {
+		bookmarks, err := fetch(page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, bookmarks...)
+		if len(bookmarks) < exportPageSize {
+			break
+		}
+	}
+	for i := range all {
+		highlights, err := s.HighlightRepo.ListHighlights(int(all[i].ID))
+		if err != nil {
+			return nil, err
+		}
+		all[i].Highlights = highlights
+	}
+	return all, nil
+}
+
+// WritePinboardJSON writes bookmarks as a Pinboard JSON export
+func (s *ExportService) WritePinboardJSON(w io.Writer, bookmarks []models.Bookmark) error {
+	out := make([]exportedBookmark, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		out = append(out, exportedBookmark{
+			PinboardBookmark: PinboardBookmark{
+				Href:        b.URL,
+				Description: b.Title,
+				Extended:    derefString(b.Description),
+				Time:        b.CreatedAt.UTC().Format(time.RFC3339),
+				Tags:        strings.Join(tagNames(b.Tags), " "),
+				Shared:      pinboardShared(b.Visibility),
+				ToRead:      pinboardToRead(b.ReadState),
+			},
+			Notes:      derefString(b.Notes),
+			Highlights: b.Highlights,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteNetscapeHTML writes bookmarks as a Netscape bookmark file with a single folder named title
+func (s *ExportService) WriteNetscapeHTML(w io.Writer, title string, bookmarks []models.Bookmark) error {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	sb.WriteString("<META HTTP-EQUIV=\"Content-Type\" CONTENT=\"text/html; charset=UTF-8\">\n")
+	sb.WriteString("<TITLE>Bookmarks</TITLE>\n<H1>Bookmarks</H1>\n<DL><p>\n")
+	fmt.Fprintf(&sb, "<DT><H3>%s</H3>\n<DL><p>\n", html.EscapeString(title))
+	for _, b := range bookmarks {
+		private := 1
+		if b.Visibility == models.VisibilityPublic {
+			private = 0
+		}
+		fmt.Fprintf(&sb, "<DT><A HREF=\"%s\" ADD_DATE=\"%d\" PRIVATE=\"%d\" TAGS=\"%s\">%s</A>\n",
+			html.EscapeString(b.URL),
+			b.CreatedAt.Unix(),
+			private,
+			html.EscapeString(strings.Join(tagNames(b.Tags), ",")),
+			html.EscapeString(b.Title),
+		)
+		var details []string
+		if description := derefString(b.Description); description != "" {
+			details = append(details, description)
+		}
+		if notes := derefString(b.Notes); notes != "" {
+			details = append(details, notes)
+		}
+		for _, h := range b.Highlights {
+			details = append(details, "> "+h.Quote)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&sb, "<DD>%s\n", html.EscapeString(strings.Join(details, "\n\n")))
+		}
+	}
+	sb.WriteString("</DL><p>\n</DL><p>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// pinboardShared maps a bookmark visibility to Pinboard's shared flag
+func pinboardShared(visibility string) string {
+	if visibility == models.VisibilityPublic {
+		return "yes"
+	}
+	return "no"
+}
+
+// pinboardToRead maps a reading state to Pinboard's toread flag
+func pinboardToRead(readState *string) string {
+	if readState != nil && (*readState == models.ReadStateUnread || *readState == models.ReadStateReading) {
+		return "yes"
+	}
+	return "no"
+}
+
+// tagNames returns the names of the given bookmark tags
+func tagNames(tags []models.BookmarkTag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// derefString returns the value of s, or "" if s is nil
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
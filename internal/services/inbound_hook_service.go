@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrInvalidHookSignature is returned for hook requests whose signature or timestamp does not check out.
+var ErrInvalidHookSignature = errors.New("invalid hook signature")
+
+// ErrInvalidHookPayload is returned for hook requests that are not a JSON object of the expected shape.
+var ErrInvalidHookPayload = errors.New("invalid hook payload")
+
+// HookPayload is the JSON body of an inbound hook. Text is read like a Telegram message, so links and
+// #hashtags in it are saved; URL, URLs, Tags and Note add to what Text yields. ReadState overrides
+// HOOK_DEFAULT_READ_STATE, or DEFAULT_READ_STATE when that is not set.
+type HookPayload struct {
+	URL       string   `json:"url"`
+	URLs      []string `json:"urls"`
+	Text      string   `json:"text"`
+	Tags      []string `json:"tags"`
+	Note      string   `json:"note"`
+	ReadState *string  `json:"read_state"`
+}
+
+// InboundHookService manages signed webhooks and saves the bookmarks posted to them.
+// Senders sign "<timestamp>.<body>" with HMAC-SHA256 keyed with the hook's secret and send
+// X-Bookmarker-Timestamp (Unix seconds) and X-Bookmarker-Signature: sha256=<hex>.
+type InboundHookService struct {
+	Bookmarks BookmarkService
+	HookRepo  *repositories.InboundHookRepository
+}
+
+func NewInboundHookService(bookmarks BookmarkService, hookRepo *repositories.InboundHookRepository) *InboundHookService {
+	return &InboundHookService{Bookmarks: bookmarks, HookRepo: hookRepo}
+}
+
+// CreateHook creates a hook for the user; the returned hook carries the secret, which is not shown again
+func (s *InboundHookService) CreateHook(userID int, name string) (models.InboundHook, error) {
+	id, err := generateSecureToken(16)
+	if err != nil {
+		return models.InboundHook{}, err
+	}
+	secret, err := generateSecureToken(32)
+	if err != nil {
+		return models.InboundHook{}, err
+	}
+	hook := models.InboundHook{
+		ID:        id,
+		UserID:    int64(userID),
+		Name:      strings.TrimSpace(name),
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.HookRepo.CreateHook(hook); err != nil {
+		return models.InboundHook{}, err
+	}
+	return hook, nil
+}
+
+func (s *InboundHookService) ListHooks(userID int) ([]models.InboundHook, error) {
+	return s.HookRepo.ListHooks(userID)
+}
+
+func (s *InboundHookService) DeleteHook(userID int, id string) error {
+	return s.HookRepo.DeleteHook(userID, id)
+}
+
+// Receive verifies a request to the hook and saves one bookmark per link in it for the hook's user
+func (s *InboundHookService) Receive(id string, timestamp string, signature string, body []byte) ([]models.Bookmark, error) {
+	hook, err := s.HookRepo.GetHook(id)
+	if err != nil {
+		return nil, err
+	}
+	if !VerifyHookSignature(hook.Secret, timestamp, body, signature, time.Now()) {
+		return nil, ErrInvalidHookSignature
+	}
+	var payload HookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrInvalidHookPayload
+	}
+
+	save := ParseLinkText(payload.Text)
+	seen := map[string]bool{}
+	for _, link := range save.URLs {
+		seen[link] = true
+	}
+	for _, link := range append([]string{payload.URL}, payload.URLs...) {
+		if link = strings.TrimSpace(link); isValidURL(link) {
+			save.URLs = appendUnique(save.URLs, seen, link)
+		}
+	}
+	save.Tags = append(save.Tags, payload.Tags...)
+	if note := strings.TrimSpace(payload.Note); note != "" {
+		save.Note = note
+	}
+	readState := defaultReadState("HOOK_DEFAULT_READ_STATE")
+	if payload.ReadState != nil {
+		if *payload.ReadState != "" && !models.IsValidReadState(*payload.ReadState) {
+			return nil, ErrInvalidReadState
+		}
+		readState = *payload.ReadState
+	}
+
+	if err := s.HookRepo.TouchHook(hook.ID); err != nil {
+		log.Printf("Failed to update inbound hook %s: %v", hook.ID, err)
+	}
+	if len(save.URLs) == 0 {
+		return []models.Bookmark{}, nil
+	}
+	return saveLinks(s.Bookmarks, hook.UserID, save, readState)
+}
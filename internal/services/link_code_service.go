@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// linkCodeTTL is how long a link code can be sent to the bot or a slash command
+const linkCodeTTL = 10 * time.Minute
+
+// linkCodeAlphabet leaves out characters that are easily confused when typed on a phone
+const linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// linkCodeLength is the number of code characters, giving 50 bits of randomness
+const linkCodeLength = 10
+
+// ErrInvalidLinkProvider is returned when asking for a link code for a provider that cannot be linked.
+var ErrInvalidLinkProvider = errors.New("invalid link provider")
+
+// LinkCodeService issues the one-time codes that link a Telegram chat, Slack account or Discord account to a user.
+// A code only links the provider it was issued for.
+type LinkCodeService struct {
+	LinkCodeRepo *repositories.LinkCodeRepository
+}
+
+func NewLinkCodeService(linkCodeRepo *repositories.LinkCodeRepository) *LinkCodeService {
+	return &LinkCodeService{LinkCodeRepo: linkCodeRepo}
+}
+
+// CreateLinkCode issues a code for the provider. Only the user's latest code for each provider works.
+func (s *LinkCodeService) CreateLinkCode(userID int, provider string) (string, time.Time, error) {
+	switch provider {
+	case models.ChatProviderTelegram, models.ChatProviderSlack, models.ChatProviderDiscord:
+	default:
+		return "", time.Time{}, ErrInvalidLinkProvider
+	}
+	raw := make([]byte, linkCodeLength)
+	max := big.NewInt(int64(len(linkCodeAlphabet)))
+	for i := range raw {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		raw[i] = linkCodeAlphabet[n.Int64()]
+	}
+	code := string(raw[:linkCodeLength/2]) + "-" + string(raw[linkCodeLength/2:])
+	expiresAt := time.Now().Add(linkCodeTTL)
+	if err := s.LinkCodeRepo.CreateLinkCode(userID, provider, repositories.HashToken(normalizeLinkCode(code)), expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+	return code, expiresAt, nil
+}
+
+// LinkInstructions tells the user where to send a code issued for the provider
+func LinkInstructions(provider string, code string) string {
+	switch provider {
+	case models.ChatProviderSlack:
+		return "Run /bookmarks link " + code + " in Slack"
+	case models.ChatProviderDiscord:
+		return "Run /bookmarks link " + code + " in Discord"
+	default:
+		return "Send /link " + code + " to the Telegram bot"
+	}
+}
+
+// normalizeLinkCode accepts codes typed in lowercase or without the dash
+func normalizeLinkCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(c rune) rune {
+		if c == '-' || c == ' ' {
+			return -1
+		}
+		return c
+	}, code)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// LinkSave is what a message asks to save: every link in it, its #hashtags as tags and the rest of the text as a note
+type LinkSave struct {
+	URLs []string
+	Tags []string
+	Note string
+}
+
+// ParseLinkText splits plain text on whitespace: http(s) words are links, #words are tags and the rest is the note.
+// Telegram messages without entities, slash commands and inbound hooks are all read this way.
+func ParseLinkText(text string) LinkSave {
+	var save LinkSave
+	var noteWords []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		var lineWords []string
+		for _, word := range strings.Fields(line) {
+			switch {
+			case isValidURL(word):
+				save.URLs = appendUnique(save.URLs, seen, word)
+			case strings.HasPrefix(word, "#") && len(word) > 1:
+				save.Tags = append(save.Tags, word[1:])
+			default:
+				lineWords = append(lineWords, word)
+			}
+		}
+		noteWords = append(noteWords, strings.Join(lineWords, " "))
+	}
+	save.Note = cleanNote(strings.Join(noteWords, "\n"))
+	return save
+}
+
+// saveLinks creates one bookmark per link for the user, with the tags and note of the message.
+// Title, description and thumbnail are left empty and filled from the link preview.
+func saveLinks(bookmarks BookmarkService, userID int64, save LinkSave, readState string) ([]models.Bookmark, error) {
+	saved := make([]models.Bookmark, 0, len(save.URLs))
+	for _, url := range save.URLs {
+		bookmark, err := bookmarks.CreateBookmarkWithTags(BookmarkInput{
+			URL:       url,
+			Tags:      save.Tags,
+			Notes:     save.Note,
+			CreatedAt: time.Now(),
+			UserID:    &userID,
+			ReadState: readState,
+		})
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, bookmark)
+	}
+	return saved, nil
+}
+
+// defaultReadState returns the reading state for bookmarks saved through a gateway: its own variable, such as
+// EMAIL_DEFAULT_READ_STATE, when set, otherwise DEFAULT_READ_STATE, otherwise "unread". Set either empty to save references.
+func defaultReadState(key string) string {
+	for _, name := range []string{key, "DEFAULT_READ_STATE"} {
+		if state, ok := os.LookupEnv(name); ok {
+			return state
+		}
+	}
+	return models.ReadStateUnread
+}
+
+// isValidURL validates if a string is a valid URL with http or https scheme
+func isValidURL(str string) bool {
+	u, err := url.ParseRequestURI(str)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// cleanNote collapses the spaces left behind by removed links and trims empty lines
+func cleanNote(note string) string {
+	lines := strings.Split(note, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func appendUnique(values []string, seen map[string]bool, value string) []string {
+	if seen[value] {
+		return values
+	}
+	seen[value] = true
+	return append(values, value)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoginThrottledError is returned while a username or IP address has to wait before trying to log in again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts; retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottlePolicy decides how long a key has to wait after repeated failed logins.
+// The first FreeAttempts failures cost nothing; each further one doubles the delay, starting at BaseDelay.
+// From MaxAttempts failures on, the key is locked out for LockoutDuration.
+// Failures older than LockoutDuration are forgotten.
+type LoginThrottlePolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxAttempts     int
+	LockoutDuration time.Duration
+}
+
+// Delay returns how long to wait after the given number of consecutive failures, and whether that is a lockout
+func (p LoginThrottlePolicy) Delay(failures int) (time.Duration, bool) {
+	if failures >= p.MaxAttempts {
+		return p.LockoutDuration, true
+	}
+	if failures <= p.FreeAttempts {
+		return 0, false
+	}
+	delay := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(failures-p.FreeAttempts-1)))
+	if delay > p.LockoutDuration {
+		delay = p.LockoutDuration
+	}
+	return delay, false
+}
+
+// loginThrottlePoliciesFromEnv reads LOGIN_MAX_ATTEMPTS, LOGIN_MAX_ATTEMPTS_PER_IP and LOGIN_LOCKOUT_DURATION.
+// An IP address may be shared by many users, so it gets more attempts than a single username.
+func loginThrottlePoliciesFromEnv() (LoginThrottlePolicy, LoginThrottlePolicy) {
+	lockout := 15 * time.Minute
+	if d, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION")); err == nil && d > 0 {
+		lockout = d
+	}
+	username := LoginThrottlePolicy{FreeAttempts: 3, BaseDelay: time.Second, MaxAttempts: 10, LockoutDuration: lockout}
+	ip := LoginThrottlePolicy{FreeAttempts: 10, BaseDelay: time.Second, MaxAttempts: 50, LockoutDuration: lockout}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS")); err == nil && n > 0 {
+		username.MaxAttempts = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS_PER_IP")); err == nil && n > 0 {
+		ip.MaxAttempts = n
+	}
+	return username, ip
+}
+
+// LoginThrottleService tracks failed logins per username and per IP address and enforces the backoff
+type LoginThrottleService struct {
+	ThrottleRepo   *repositories.LoginThrottleRepository
+	UsernamePolicy LoginThrottlePolicy
+	IPPolicy       LoginThrottlePolicy
+}
+
+func NewLoginThrottleService(throttleRepo *repositories.LoginThrottleRepository) *LoginThrottleService {
+	usernamePolicy, ipPolicy := loginThrottlePoliciesFromEnv()
+	return &LoginThrottleService{ThrottleRepo: throttleRepo, UsernamePolicy: usernamePolicy, IPPolicy: ipPolicy}
+}
+
+// throttleKeys returns the keys a login is tracked under; usernames are compared case-insensitively
+func throttleKeys(username, ipAddress string) map[string]string {
+	keys := map[string]string{models.ThrottleScopeUsername: strings.ToLower(username)}
+	if ipAddress != "" {
+		keys[models.ThrottleScopeIP] = ipAddress
+	}
+	return keys
+}
+
+func (s *LoginThrottleService) policy(scope string) LoginThrottlePolicy {
+	if scope == models.ThrottleScopeIP {
+		return s.IPPolicy
+	}
+	return s.UsernamePolicy
+}
+
+// Check returns a *LoginThrottledError if the username or IP address has to wait before the next attempt
+func (s *LoginThrottleService) Check(username, ipAddress string) error {
+	now := time.Now()
+	var wait time.Duration
+	for scope, key := range throttleKeys(username, ipAddress) {
+		t, err := s.ThrottleRepo.GetThrottle(scope, key)
+		if err != nil {
+			return err
+		}
+		if t.LockedUntil != nil && t.LockedUntil.After(now) && t.LockedUntil.Sub(now) > wait {
+			wait = t.LockedUntil.Sub(now)
+		}
+	}
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login against the username and IP address and starts their backoff.
+// It reports whether this failure locked one of them out.
+func (s *LoginThrottleService) RecordFailure(username, ipAddress string) (bool, error) {
+	now := time.Now()
+	lockedOut := false
+	for scope, key := range throttleKeys(username, ipAddress) {
+		policy := s.policy(scope)
+		failures, err := s.ThrottleRepo.RecordFailure(scope, key, now, now.Add(-policy.LockoutDuration))
+		if err != nil {
+			return false, err
+		}
+		delay, lockout := policy.Delay(failures)
+		if delay == 0 {
+			continue
+		}
+		// Report a lockout only once, when the threshold is reached
+		lockedOut = lockedOut || (lockout && failures == policy.MaxAttempts)
+		if err := s.ThrottleRepo.SetLockedUntil(scope, key, now.Add(delay)); err != nil {
+			return false, err
+		}
+	}
+	return lockedOut, nil
+}
+
+// RecordSuccess forgets earlier failures of the username. The IP address keeps its count:
+// otherwise logging into one account would let an attacker reset the limit for guessing others.
+func (s *LoginThrottleService) RecordSuccess(username string) error {
+	return s.ThrottleRepo.ResetThrottle(models.ThrottleScopeUsername, strings.ToLower(username))
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bookmarker/internal/clients"
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OIDCLoginStateTTL is how long a user may take at the identity provider
+const OIDCLoginStateTTL = 10 * time.Minute
+
+// ErrOIDCDisabled is returned when no identity provider is configured.
+var ErrOIDCDisabled = errors.New("OIDC login is not configured")
+
+// ErrInvalidOIDCState is returned for a callback whose state is unknown, used or expired.
+var ErrInvalidOIDCState = errors.New("invalid or expired OIDC login state")
+
+// ErrOIDCNoUsername is returned when the ID token lacks the claim users are matched and created by.
+var ErrOIDCNoUsername = errors.New("identity provider did not return a usable username")
+
+// ErrOIDCUserNotProvisioned is returned when the provider account is not linked to a user and may not be linked or created.
+var ErrOIDCUserNotProvisioned = errors.New("no user is linked to this identity provider account")
+
+// ErrOIDCLinkRequiresLogin is returned instead of linking a provider account to a user with two-factor authentication,
+// which the provider login would bypass; the user links the account after logging in with their second factor.
+var ErrOIDCLinkRequiresLogin = errors.New("log in with your password and second factor, then link this identity provider account")
+
+// ErrOIDCIdentityInUse is returned when a user links a provider account that is already linked to another user.
+var ErrOIDCIdentityInUse = errors.New("this identity provider account is linked to another user")
+
+// OIDCConfig configures single sign-on with an OpenID Connect provider.
+// It is read from the environment:
+//
+//	OIDC_ISSUER               issuer URL; OIDC login is disabled when empty
+//	OIDC_CLIENT_ID            client registered at the provider
+//	OIDC_CLIENT_SECRET        client secret; empty for public clients relying on PKCE alone
+//	OIDC_REDIRECT_URL         callback URL registered at the provider (default APP_URL/auth/oidc/callback)
+//	OIDC_SCOPES               requested scopes (default "openid profile email")
+//	OIDC_USERNAME_CLAIM       claim new users are named after (default preferred_username)
+//	OIDC_LINK_EXISTING_USERS  link a provider account to the user whose username is the account's verified email,
+//	                          unless that user has two-factor authentication enabled (default false)
+//	OIDC_AUTO_CREATE_USERS    create users on first login (default true)
+//	OIDC_POST_LOGIN_URL       base URL the browser returns to after login (default APP_URL)
+type OIDCConfig struct {
+	Issuer            string
+	ClientID          string
+	ClientSecret      string
+	RedirectURL       string
+	Scopes            []string
+	UsernameClaim     string
+	LinkExistingUsers bool
+	AutoCreateUsers   bool
+	PostLoginURL      string
+}
+
+// OIDCConfigFromEnv reads the OIDC configuration from the environment
+func OIDCConfigFromEnv() OIDCConfig {
+	appURL := strings.TrimRight(os.Getenv("APP_URL"), "/")
+	config := OIDCConfig{
+		Issuer:            os.Getenv("OIDC_ISSUER"),
+		ClientID:          os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret:      os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:       appURL + "/auth/oidc/callback",
+		Scopes:            []string{"openid", "profile", "email"},
+		UsernameClaim:     "preferred_username",
+		LinkExistingUsers: false,
+		AutoCreateUsers:   true,
+		PostLoginURL:      appURL,
+	}
+	if v := os.Getenv("OIDC_REDIRECT_URL"); v != "" {
+		config.RedirectURL = v
+	}
+	if v := strings.Fields(os.Getenv("OIDC_SCOPES")); len(v) > 0 {
+		config.Scopes = v
+	}
+	if v := os.Getenv("OIDC_USERNAME_CLAIM"); v != "" {
+		config.UsernameClaim = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("OIDC_LINK_EXISTING_USERS")); err == nil {
+		config.LinkExistingUsers = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("OIDC_AUTO_CREATE_USERS")); err == nil {
+		config.AutoCreateUsers = v
+	}
+	if v := os.Getenv("OIDC_POST_LOGIN_URL"); v != "" {
+		config.PostLoginURL = strings.TrimRight(v, "/")
+	}
+	return config
+}
+
+// Enabled reports whether an identity provider is configured
+func (c OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
+// OIDCService runs the authorization code flow with PKCE and maps provider accounts to users
+type OIDCService struct {
+	Config      OIDCConfig
+	Client      *clients.OIDCClient
+	OIDCRepo    *repositories.OIDCRepository
+	UserService *UserService
+}
+
+func NewOIDCService(config OIDCConfig, oidcRepo *repositories.OIDCRepository, userService *UserService) *OIDCService {
+	return &OIDCService{
+		Config:      config,
+		Client:      clients.NewOIDCClient(config.Issuer, config.ClientID, config.ClientSecret),
+		OIDCRepo:    oidcRepo,
+		UserService: userService,
+	}
+}
+
+// BeginLogin stores a new login state and returns the provider URL to send the browser to, along with the
+// state's hash, which the browser has to present again on the callback. redirectPath is where the browser
+// goes after the login completes.
+func (s *OIDCService) BeginLogin(redirectPath string) (string, string, error) {
+	return s.beginLogin(redirectPath, nil)
+}
+
+// BeginLink is BeginLogin for a signed-in user: the provider account is linked to that user
+// rather than matched, so it works whatever the account's username or email.
+func (s *OIDCService) BeginLink(userID int64, redirectPath string) (string, string, error) {
+	return s.beginLogin(redirectPath, &userID)
+}
+
+func (s *OIDCService) beginLogin(redirectPath string, linkUserID *int64) (string, string, error) {
+	if !s.Config.Enabled() {
+		return "", "", ErrOIDCDisabled
+	}
+	state, err := generateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := generateSecureToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := generateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	authURL, err := s.Client.AuthCodeURL(s.Config.RedirectURL, s.Config.Scopes, state, nonce,
+		base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", "", err
+	}
+	stateHash := repositories.HashToken(state)
+	err = s.OIDCRepo.CreateLoginState(models.OIDCLoginState{
+		StateHash:    stateHash,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		RedirectPath: safeRedirectPath(redirectPath),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(OIDCLoginStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, stateHash, nil
+}
+
+// CompleteLogin handles the provider's callback: it exchanges the code, validates the ID token
+// and returns the user it belongs to together with the path to redirect the browser to
+func (s *OIDCService) CompleteLogin(code, state string) (models.User, string, error) {
+	if !s.Config.Enabled() {
+		return models.User{}, "", ErrOIDCDisabled
+	}
+	loginState, err := s.OIDCRepo.ConsumeLoginState(repositories.HashToken(state))
+	if errors.Is(err, repositories.ErrLoginStateNotFound) || (err == nil && time.Now().After(loginState.ExpiresAt)) {
+		return models.User{}, "", ErrInvalidOIDCState
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+	tokens, err := s.Client.Exchange(code, s.Config.RedirectURL, loginState.CodeVerifier)
+	if err != nil {
+		return models.User{}, "", err
+	}
+	claims, err := s.Client.VerifyIDToken(tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		return models.User{}, "", err
+	}
+	var user models.User
+	if loginState.LinkUserID != nil {
+		user, err = s.linkUser(*loginState.LinkUserID, claims)
+	} else {
+		user, err = s.resolveUser(claims)
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+	return user, loginState.RedirectPath, nil
+}
+
+// linkUser links the provider account to the signed-in user who started the login
+func (s *OIDCService) linkUser(userID int64, claims *clients.OIDCClaims) (models.User, error) {
+	identity, err := s.OIDCRepo.GetIdentity(s.Client.Issuer, claims.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return models.User{}, ErrOIDCIdentityInUse
+		}
+		return s.UserService.GetUserByID(userID)
+	}
+	if !errors.Is(err, repositories.ErrIdentityNotFound) {
+		return models.User{}, err
+	}
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if _, err := s.OIDCRepo.CreateIdentity(user.ID, s.Client.Issuer, claims.Subject, verifiedEmail(claims)); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// resolveUser finds the user linked to the provider account. Unlinked accounts are linked to the user
+// named after their verified email or get a new user, as configured. Usernames are never matched,
+// since the username claim is often editable at the provider.
+func (s *OIDCService) resolveUser(claims *clients.OIDCClaims) (models.User, error) {
+	var email *string
+	if claims.Email != "" {
+		email = &claims.Email
+	}
+	identity, err := s.OIDCRepo.GetIdentity(s.Client.Issuer, claims.Subject)
+	if err == nil {
+		if err := s.OIDCRepo.TouchIdentity(identity.ID, email); err != nil {
+			log.Printf("Failed to update identity %d: %v", identity.ID, err)
+		}
+		return s.UserService.GetUserByID(identity.UserID)
+	}
+	if !errors.Is(err, repositories.ErrIdentityNotFound) {
+		return models.User{}, err
+	}
+
+	if email := verifiedEmail(claims); email != nil && s.Config.LinkExistingUsers {
+		user, err := s.UserService.GetUserByUsername(*email)
+		if err == nil {
+			if user.TwoFactorEnabled {
+				return models.User{}, ErrOIDCLinkRequiresLogin
+			}
+			if _, err := s.OIDCRepo.CreateIdentity(user.ID, s.Client.Issuer, claims.Subject, email); err != nil {
+				return models.User{}, err
+			}
+			return user, nil
+		}
+		if !errors.Is(err, repositories.ErrUserNotFound) {
+			return models.User{}, err
+		}
+	}
+
+	username, err := s.username(claims)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := s.UserService.GetUserByUsername(username)
+	switch {
+	case err == nil:
+		// The name is taken by a user the account was not linked to
+		return models.User{}, ErrOIDCUserNotProvisioned
+	case errors.Is(err, repositories.ErrUserNotFound) && s.Config.AutoCreateUsers:
+		// Users created here sign in through the provider; their random password is never handed out
+		password, err := generateSecureToken(32)
+		if err != nil {
+			return models.User{}, err
+		}
+		if user, err = s.UserService.CreateUser(username, password); err != nil {
+			return models.User{}, err
+		}
+	case errors.Is(err, repositories.ErrUserNotFound):
+		return models.User{}, ErrOIDCUserNotProvisioned
+	default:
+		return models.User{}, err
+	}
+	if _, err := s.OIDCRepo.CreateIdentity(user.ID, s.Client.Issuer, claims.Subject, email); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// verifiedEmail returns the account's email if the provider verified it
+func verifiedEmail(claims *clients.OIDCClaims) *string {
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return nil
+	}
+	return &email
+}
+
+// username reads the configured username claim. An email address is only trusted once the provider verified it.
+func (s *OIDCService) username(claims *clients.OIDCClaims) (string, error) {
+	value, _ := claims.Raw[s.Config.UsernameClaim].(string)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", ErrOIDCNoUsername
+	}
+	if s.Config.UsernameClaim == "email" && !claims.EmailVerified {
+		return "", fmt.Errorf("%w: email is not verified", ErrOIDCNoUsername)
+	}
+	return value, nil
+}
+
+// PostLoginURL is the absolute URL for a redirect path stored with a login
+func (s *OIDCService) PostLoginURL(redirectPath string) string {
+	return s.Config.PostLoginURL + safeRedirectPath(redirectPath)
+}
+
+// safeRedirectPath only allows local paths, so the login cannot be turned into an open redirect
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "\\") {
+		return "/"
+	}
+	return path
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bookmarker/internal/models"
 	"encoding/json"
 	"errors"
 	"io"
@@ -15,6 +16,8 @@ type PinboardBookmark struct {
 	Extended    string `json:"extended"`
 	Time        string `json:"time"`
 	Tags        string `json:"tags"`
+	Shared      string `json:"shared"`
+	ToRead      string `json:"toread"`
 }
 
 var placeholderThumbnails = []string{
@@ -29,6 +32,8 @@ var placeholderThumbnails = []string{
 // and uses the existing BookmarkService to create bookmarks with tags.
 type PinboardImportService struct {
 	BookmarkService BookmarkService
+	// UserID owns the imported bookmarks; nil leaves them unowned and hidden until assign-bookmarks gives them an owner
+	UserID *int64
 }
 
 func NewPinboardImportService(bookmarkService BookmarkService) *PinboardImportService {
@@ -62,14 +67,17 @@ func (s *PinboardImportService) ImportFromJSON(r io.Reader) error {
 			createdAt = time.Now()
 		}
 
-		_, err = s.BookmarkService.CreateBookmarkWithTags(
-			pb.Href,
-			pb.Description,
-			description,
-			thumbnail,
-			tags,
-			createdAt,
-		)
+		_, err = s.BookmarkService.CreateBookmarkWithTags(BookmarkInput{
+			URL:         pb.Href,
+			Title:       pb.Description,
+			Description: description,
+			Thumbnail:   thumbnail,
+			Tags:        tags,
+			CreatedAt:   createdAt,
+			UserID:      s.UserID,
+			Visibility:  pinboardVisibility(pb.Shared),
+			ReadState:   pinboardReadState(pb.ToRead),
+		})
 		if err != nil {
 			return err
 		}
@@ -77,6 +85,22 @@ func (s *PinboardImportService) ImportFromJSON(r io.Reader) error {
 	return nil
 }
 
+// pinboardVisibility maps Pinboard's shared flag to a bookmark visibility
+func pinboardVisibility(shared string) string {
+	if shared == "yes" {
+		return models.VisibilityPublic
+	}
+	return models.VisibilityPrivate
+}
+
+// pinboardReadState queues bookmarks flagged "toread" as unread
+func pinboardReadState(toRead string) string {
+	if toRead == "yes" {
+		return models.ReadStateUnread
+	}
+	return ""
+}
+
 func parseTags(tags string) []string {
 	if tags == "" {
 		return nil
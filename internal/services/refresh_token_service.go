@@ -14,8 +14,8 @@ func NewRefreshTokenService(refreshTokenRepo *repositories.RefreshTokenRepositor
 	return &RefreshTokenService{RefreshTokenRepo: refreshTokenRepo}
 }
 
-func (s *RefreshTokenService) CreateRefreshToken(userID int, refreshToken string, expiresAt time.Time) error {
-	return s.RefreshTokenRepo.CreateRefreshToken(userID, refreshToken, expiresAt)
+func (s *RefreshTokenService) CreateRefreshToken(userID int, sessionID int, refreshToken string, expiresAt time.Time) error {
+	return s.RefreshTokenRepo.CreateRefreshToken(userID, sessionID, refreshToken, expiresAt)
 }
 
 func (s *RefreshTokenService) FindByToken(refreshToken string) (*models.RefreshToken, error) {
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signatureMaxAge is how old a signed request may be, limiting how long a captured request can be replayed
+const signatureMaxAge = 5 * time.Minute
+
+// VerifySlackSignature checks the X-Slack-Signature header, "v0=" followed by the hex HMAC-SHA256
+// of "v0:<timestamp>:<body>" keyed with the app's signing secret
+func VerifySlackSignature(signingSecret string, timestamp string, body []byte, signature string, now time.Time) bool {
+	if signingSecret == "" || !freshTimestamp(timestamp, now) {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// VerifyDiscordSignature checks the X-Signature-Ed25519 header, an Ed25519 signature of timestamp
+// followed by the body, against the application's hex-encoded public key
+func VerifyDiscordSignature(publicKeyHex string, timestamp string, body []byte, signature string, now time.Time) bool {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize || !freshTimestamp(timestamp, now) {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(publicKey), append([]byte(timestamp), body...), sig)
+}
+
+// VerifyHookSignature checks the X-Bookmarker-Signature header of an inbound hook, "sha256=" followed
+// by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the hook's secret
+func VerifyHookSignature(secret string, timestamp string, body []byte, signature string, now time.Time) bool {
+	if secret == "" || !freshTimestamp(timestamp, now) {
+		return false
+	}
+	expected := "sha256=" + hex.EncodeToString(HookSignature(secret, timestamp, body))
+	return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected))
+}
+
+// HookSignature is the HMAC-SHA256 senders of inbound hooks compute over "<timestamp>.<body>"
+func HookSignature(secret string, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// freshTimestamp reports whether a Unix timestamp in seconds lies within signatureMaxAge of now
+func freshTimestamp(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	return age < signatureMaxAge && age > -signatureMaxAge
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+)
+
+type SessionService struct {
+	SessionRepo *repositories.SessionRepository
+}
+
+func NewSessionService(sessionRepo *repositories.SessionRepository) *SessionService {
+	return &SessionService{SessionRepo: sessionRepo}
+}
+
+func (s *SessionService) CreateSession(userID int, userAgent, ipAddress string) (models.Session, error) {
+	return s.SessionRepo.CreateSession(userID, userAgent, ipAddress)
+}
+
+func (s *SessionService) ListSessions(userID int) ([]models.Session, error) {
+	return s.SessionRepo.ListSessions(userID)
+}
+
+func (s *SessionService) DeleteSession(id int) error {
+	return s.SessionRepo.DeleteSession(id)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrShareNotFound is returned when a share link does not exist, was revoked or belongs to another user.
+var ErrShareNotFound = errors.New("share link not found")
+
+// maxSharedPageSize caps the page size of public pages and feeds
+const maxSharedPageSize = 100
+
+// ShareRequest describes what a new share link publishes
+type ShareRequest struct {
+	Kind         string
+	CollectionID int
+	Tag          string
+	Query        string
+	Title        string
+}
+
+// ShareService publishes collections, tags and saved searches as read-only public pages.
+type ShareService interface {
+	CreateShare(userID int, req ShareRequest) (models.ShareLink, error)
+	ListShares(userID int) ([]models.ShareLink, error)
+	RevokeShare(userID int, id int) error
+	// GetSharedBookmarks resolves an active share token to the share link and the public view of its bookmarks
+	GetSharedBookmarks(token string, page int, pageSize int) (models.ShareLink, []models.PublicBookmark, error)
+}
+
+type shareService struct {
+	repo              repositories.ShareLinkRepository
+	bookmarkRepo      repositories.BookmarkRepository
+	tagRepo           repositories.TagRepository
+	collectionService CollectionService
+	collectionRepo    repositories.CollectionRepository
+}
+
+// NewShareService creates a new instance of the shareService.
+func NewShareService(repo repositories.ShareLinkRepository, bookmarkRepo repositories.BookmarkRepository, tagRepo repositories.TagRepository, collectionRepo repositories.CollectionRepository) ShareService {
+	return &shareService{
+		repo:              repo,
+		bookmarkRepo:      bookmarkRepo,
+		tagRepo:           tagRepo,
+		collectionRepo:    collectionRepo,
+		collectionService: NewCollectionService(collectionRepo, bookmarkRepo, tagRepo),
+	}
+}
+
+// CreateShare validates the shared target and creates a share link with a fresh secret token.
+func (s *shareService) CreateShare(userID int, req ShareRequest) (models.ShareLink, error) {
+	link := models.ShareLink{UserID: int64(userID), Kind: req.Kind, Title: req.Title}
+	switch req.Kind {
+	case models.ShareKindCollection:
+		collection, err := s.collectionService.GetCollection(userID, req.CollectionID)
+		if err != nil {
+			return models.ShareLink{}, err
+		}
+		collectionID := collection.ID
+		link.CollectionID = &collectionID
+		if link.Title == "" {
+			link.Title = collection.Name
+		}
+	case models.ShareKindTag:
+		tagName := TagNormalizationRulesFromEnv().Normalize(req.Tag)
+		tag, err := s.tagRepo.GetTagByName(tagName)
+		if err != nil {
+			return models.ShareLink{}, errors.New("tag not found")
+		}
+		tagID := tag.ID
+		link.TagID = &tagID
+		if link.Title == "" {
+			link.Title = "#" + tag.Name
+		}
+	case models.ShareKindQuery:
+		query := strings.TrimSpace(req.Query)
+		if query == "" {
+			return models.ShareLink{}, errors.New("query required")
+		}
+		link.Query = &query
+		if link.Title == "" {
+			link.Title = "Search: " + query
+		}
+	default:
+		return models.ShareLink{}, errors.New("share kind must be collection, tag or query")
+	}
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	link.Token = token
+	created, err := s.repo.CreateShareLink(link)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	return withShareURL(created), nil
+}
+
+// ListShares retrieves all share links created by the user, including revoked ones.
+func (s *shareService) ListShares(userID int) ([]models.ShareLink, error) {
+	links, err := s.repo.ListShareLinks(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		links[i] = withShareURL(links[i])
+	}
+	return links, nil
+}
+
+// RevokeShare disables a share link owned by the user.
+func (s *shareService) RevokeShare(userID int, id int) error {
+	link, err := s.repo.GetShareLinkByID(id)
+	if err != nil || link.UserID != int64(userID) {
+		return ErrShareNotFound
+	}
+	return s.repo.RevokeShareLink(id)
+}
+
+// GetSharedBookmarks resolves an active share token to the public view of its bookmarks.
+func (s *shareService) GetSharedBookmarks(token string, page int, pageSize int) (models.ShareLink, []models.PublicBookmark, error) {
+	link, err := s.repo.GetShareLinkByToken(token)
+	if err != nil {
+		return models.ShareLink{}, nil, ErrShareNotFound
+	}
+	if pageSize > maxSharedPageSize {
+		pageSize = maxSharedPageSize
+	}
+	offset := (page - 1) * pageSize
+
+	// Public pages only ever show public bookmarks; tag and query views are limited to the owner's bookmarks
+	publicOnly := repositories.BookmarkFilter{Visibilities: []string{models.VisibilityPublic}}
+	ownPublic := repositories.BookmarkFilter{OwnerID: int(link.UserID), Visibilities: []string{models.VisibilityPublic}}
+	var bookmarks []models.Bookmark
+	switch link.Kind {
+	case models.ShareKindCollection:
+		bookmarks, err = s.collectionRepo.ListBookmarksInCollection(int(*link.CollectionID), publicOnly, offset, pageSize)
+	case models.ShareKindTag:
+		bookmarks, err = s.bookmarkRepo.ListBookmarksByTag(int(*link.TagID), ownPublic, offset, pageSize)
+	case models.ShareKindQuery:
+		bookmarks, err = s.bookmarkRepo.SearchBookmarks(*link.Query, ownPublic, offset, pageSize)
+	default:
+		return models.ShareLink{}, nil, ErrShareNotFound
+	}
+	if err != nil {
+		return models.ShareLink{}, nil, err
+	}
+
+	public := make([]models.PublicBookmark, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		tags, err := s.tagRepo.GetTagsForBookmark(int(b.ID))
+		if err != nil {
+			return models.ShareLink{}, nil, err
+		}
+		public = append(public, models.PublicBookmark{
+			Title:       b.Title,
+			URL:         b.URL,
+			Description: derefString(b.Description),
+			Tags:        tagNames(tags),
+			CreatedAt:   b.CreatedAt,
+		})
+	}
+	// Never expose the owner or the token of the link on public pages
+	link.UserID = 0
+	link.Token = ""
+	return link, public, nil
+}
+
+// withShareURL fills in the public URL of a share link from APP_URL
+func withShareURL(link models.ShareLink) models.ShareLink {
+	link.URL = strings.TrimRight(os.Getenv("APP_URL"), "/") + "/shared/" + link.Token
+	return link
+}
+
+// generateSecureToken returns a URL-safe random token built from n bytes of crypto/rand
+func generateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
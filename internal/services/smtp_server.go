@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// smtpCommandTimeout bounds how long a client may take for one command or message
+const smtpCommandTimeout = 5 * time.Minute
+
+// smtpMaxRecipients caps the RCPT commands accepted for one message
+const smtpMaxRecipients = 100
+
+// SMTPError is returned by an SMTPHandler to answer a message with a specific reply, such as 550 for an unknown sender.
+// Other errors are answered with 451, asking the sending server to try again later.
+type SMTPError struct {
+	Code    int
+	Message string
+}
+
+func (e *SMTPError) Error() string {
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+// SMTPHandler receives each message accepted by the SMTP server: the envelope sender and recipients and the raw message
+type SMTPHandler func(from string, to []string, data []byte) error
+
+// SMTPServer is a minimal SMTP receiver (RFC 5321) for inbound mail. It speaks plain SMTP without TLS or
+// authentication, so it belongs behind a mail server that relays to it, or on a trusted network.
+type SMTPServer struct {
+	Addr            string
+	Domain          string
+	MaxMessageBytes int64
+	Handler         SMTPHandler
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+// ListenAndServe listens on s.Addr and serves connections until Close is called
+func (s *SMTPServer) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Close is called
+func (s *SMTPServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return nil
+	}
+	s.listener = listener
+	s.mu.Unlock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(time.Second)
+				continue
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops accepting connections; sessions in progress run to completion
+func (s *SMTPServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *SMTPServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(code int, message string) {
+		text.PrintfLine("%d %s", code, message)
+	}
+	var (
+		greeted bool
+		started bool
+		from    string
+		to      []string
+	)
+	reset := func() {
+		started, from, to = false, "", nil
+	}
+
+	conn.SetDeadline(time.Now().Add(smtpCommandTimeout))
+	reply(220, s.Domain+" ESMTP bookmarker")
+	for {
+		conn.SetDeadline(time.Now().Add(smtpCommandTimeout))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			greeted = true
+			reset()
+			reply(250, s.Domain)
+		case "EHLO":
+			greeted = true
+			reset()
+			text.PrintfLine("250-%s", s.Domain)
+			text.PrintfLine("250-SIZE %d", s.MaxMessageBytes)
+			text.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			address, ok := smtpPath(arg, "FROM:")
+			switch {
+			case !greeted:
+				reply(503, "Send HELO or EHLO first")
+			case started:
+				reply(503, "Sender already specified")
+			case !ok:
+				reply(501, "Syntax: MAIL FROM:<address>")
+			default:
+				started, from = true, address
+				reply(250, "OK")
+			}
+		case "RCPT":
+			address, ok := smtpPath(arg, "TO:")
+			switch {
+			case !started:
+				reply(503, "Send MAIL first")
+			case !ok || address == "":
+				reply(501, "Syntax: RCPT TO:<address>")
+			case len(to) >= smtpMaxRecipients:
+				reply(452, "Too many recipients")
+			default:
+				to = append(to, address)
+				reply(250, "OK")
+			}
+		case "DATA":
+			if len(to) == 0 {
+				reply(503, "Send RCPT first")
+				continue
+			}
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+			dot := text.DotReader()
+			data, err := io.ReadAll(io.LimitReader(dot, s.MaxMessageBytes+1))
+			if err != nil {
+				return
+			}
+			if int64(len(data)) > s.MaxMessageBytes {
+				// Read the rest of the message so the reply is not mistaken for more data
+				if _, err := io.Copy(io.Discard, dot); err != nil {
+					return
+				}
+				reply(552, "Message too large")
+			} else {
+				s.deliver(from, to, data, reply)
+			}
+			reset()
+		case "RSET":
+			reset()
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "VRFY":
+			reply(252, "Cannot verify addresses")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// deliver passes a message to the handler and answers with its outcome
+func (s *SMTPServer) deliver(from string, to []string, data []byte, reply func(int, string)) {
+	err := s.Handler(from, to, data)
+	var smtpErr *SMTPError
+	switch {
+	case err == nil:
+		reply(250, "OK: message accepted")
+	case errors.As(err, &smtpErr):
+		reply(smtpErr.Code, smtpErr.Message)
+	default:
+		log.Printf("[SMTPServer] Failed to handle message from %q: %v", from, err)
+		reply(451, "Temporary failure, try again later")
+	}
+}
+
+// smtpPath reads the address of "FROM:<address> PARAMS" or "TO:<address>"; the null sender <> is allowed
+func smtpPath(arg string, prefix string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		fields := strings.Fields(path)
+		if len(fields) == 0 {
+			return "", false
+		}
+		return fields[0], true
+	}
+	end := strings.Index(path, ">")
+	if end < 0 {
+		return "", false
+	}
+	return path[1:end], true
+}
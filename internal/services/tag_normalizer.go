@@ -0,0 +1,89 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TagNormalizationRules controls how tag names are cleaned up before they are stored or looked up.
+// Rules are configured from the environment:
+//
+//	TAG_CASE_FOLD          lowercase tag names (default true)
+//	TAG_SPACE_REPLACEMENT  replacement for runs of whitespace inside a tag (default "-")
+//	TAG_STRIP_PUNCTUATION  drop punctuation other than - _ . + # and the hierarchy separator (default true)
+//	TAG_MAX_LENGTH         maximum tag length in characters, 0 for unlimited (default 64)
+type TagNormalizationRules struct {
+	CaseFold         bool
+	SpaceReplacement string
+	StripPunctuation bool
+	MaxLength        int
+}
+
+// TagNormalizationRulesFromEnv reads the normalization rules from the environment
+func TagNormalizationRulesFromEnv() TagNormalizationRules {
+	rules := TagNormalizationRules{
+		CaseFold:         true,
+		SpaceReplacement: "-",
+		StripPunctuation: true,
+		MaxLength:        64,
+	}
+	if v, err := strconv.ParseBool(os.Getenv("TAG_CASE_FOLD")); err == nil {
+		rules.CaseFold = v
+	}
+	if v, ok := os.LookupEnv("TAG_SPACE_REPLACEMENT"); ok {
+		rules.SpaceReplacement = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("TAG_STRIP_PUNCTUATION")); err == nil {
+		rules.StripPunctuation = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("TAG_MAX_LENGTH")); err == nil && v >= 0 {
+		rules.MaxLength = v
+	}
+	return rules
+}
+
+// Normalize applies the rules to a single tag name. It returns "" if nothing is left of the name.
+func (r TagNormalizationRules) Normalize(name string) string {
+	name = strings.TrimSpace(name)
+	if r.CaseFold {
+		name = strings.ToLower(name)
+	}
+	name = strings.Join(strings.Fields(name), r.SpaceReplacement)
+	if r.StripPunctuation {
+		name = strings.Map(func(c rune) rune {
+			if unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("-_.+#/", c) {
+				return c
+			}
+			if strings.ContainsRune(r.SpaceReplacement, c) {
+				return c
+			}
+			return -1
+		}, name)
+	}
+	if r.MaxLength > 0 {
+		if runes := []rune(name); len(runes) > r.MaxLength {
+			name = string(runes[:r.MaxLength])
+		}
+	}
+	return strings.Trim(name, "/")
+}
+
+// NormalizeAll normalizes and deduplicates tag names, keeping their first-seen order
+func (r TagNormalizationRules) NormalizeAll(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		normalized := r.Normalize(name)
+		if normalized == "" {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		result = append(result, normalized)
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTagNotFound is returned when renaming a tag that does not exist.
+var ErrTagNotFound = errors.New("tag not found")
+
+// ErrTagExists is returned when renaming a tag to the name of another tag or alias, or to a name
+// another tag or alias lies below.
+var ErrTagExists = errors.New("a tag or alias with that name already exists")
+
+// ErrInvalidTagName is returned for tag names that are empty after normalization.
+var ErrInvalidTagName = errors.New("tag name must not be empty")
+
+// ErrTagMove is returned when a rename would move a tag to another parent.
+var ErrTagMove = errors.New("a rename cannot move a tag to another parent")
+
+// TagService changes tags, which are shared by all users
+type TagService struct {
+	db *pgxpool.Pool
+}
+
+func NewTagService(db *pgxpool.Pool) *TagService {
+	return &TagService{db: db}
+}
+
+// RenameTag renames a tag and its descendants and queues tag.renamed for the subscribed webhooks of the users
+// whose bookmarks use them, all in one transaction. The new name is normalized like any other tag name.
+// Tags are shared by all users, so only admins may rename them.
+func (s *TagService) RenameTag(id int, name string) (models.Tag, error) {
+	name = TagNormalizationRulesFromEnv().Normalize(name)
+	if name == "" {
+		return models.Tag{}, ErrInvalidTagName
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer tx.Rollback(ctx)
+	tagRepo := repositories.NewTagRepository(tx)
+
+	tag, err := tagRepo.GetTagByID(id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Tag{}, ErrTagNotFound
+	}
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if name == tag.Name {
+		return tag, nil
+	}
+	if parentTagPath(name) != parentTagPath(tag.Name) {
+		return models.Tag{}, ErrTagMove
+	}
+	// Descendants are renamed too, so their new names must be free as well, and aliases must keep
+	// pointing at the tags they were made for
+	inUse, err := tagRepo.TagNameInUse(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if inUse {
+		return models.Tag{}, ErrTagExists
+	}
+
+	renamed, err := tagRepo.RenameTag(id, name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	payload, err := webhookPayload(models.WebhookEventTagRenamed, map[string]interface{}{"tag": renamed, "old_name": tag.Name})
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if err := repositories.NewWebhookRepository(tx).EnqueueTagEvent(models.WebhookEventTagRenamed, id, payload); err != nil {
+		return models.Tag{}, err
+	}
+	return renamed, tx.Commit(ctx)
+}
+
+// parentTagPath returns the path of a tag's parent, "lang" for "lang/go", or empty for a top-level tag
+func parentTagPath(name string) string {
+	i := strings.LastIndex(name, repositories.TagHierarchySeparator)
+	if i < 0 {
+		return ""
+	}
+	return name[:i]
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bookmarker/internal/clients"
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// telegramResultLimit is how many bookmarks or tags a bot reply lists
+const telegramResultLimit = 10
+
+// telegramTitleLength caps titles in bot replies, keeping replies well below Telegram's message size limit
+const telegramTitleLength = 80
+
+const telegramHelp = `<b>Send links</b> to save them. #hashtags become tags and the rest of the message becomes a note.
+
+/search &lt;query&gt; — find bookmarks
+/recent — latest bookmarks
+/unread — your reading queue
+/tags — most used tags
+/tag &lt;id&gt; &lt;tags&gt; — add tags to a bookmark
+/delete &lt;id&gt; — move a bookmark to the trash
+/unlink — stop saving from this chat
+/help — this message`
+
+const telegramLinkInstructions = `This chat is not linked to a bookmarker account yet.
+
+Create a link code in bookmarker (POST /telegram/link-code) and send it here as /link &lt;code&gt;.`
+
+// TelegramBot answers bot commands sent to the Telegram bot
+type TelegramBot struct {
+	Bookmarks BookmarkService
+	Links     *TelegramLinkService
+	Client    *clients.TelegramApiClient
+}
+
+func NewTelegramBot(bookmarks BookmarkService, links *TelegramLinkService, client *clients.TelegramApiClient) *TelegramBot {
+	return &TelegramBot{Bookmarks: bookmarks, Links: links, Client: client}
+}
+
+// IsTelegramCommand reports whether a message is a bot command such as "/search go"
+func IsTelegramCommand(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "/")
+}
+
+// AuthorizeChat returns the user a chat acts for. It handles /link itself and answers messages
+// from unlinked chats with instructions; in both cases handled is true and the message needs no further work.
+func (b *TelegramBot) AuthorizeChat(chatID int64, text string) (userID int, handled bool, err error) {
+	if command, args := parseTelegramCommand(text); IsTelegramCommand(text) && command == "/link" {
+		return 0, true, b.link(chatID, args)
+	}
+	userID, err = b.Links.ChatUserID(chatID)
+	if errors.Is(err, repositories.ErrChatNotLinked) {
+		return 0, true, b.Client.SendHTMLMessage(chatID, telegramLinkInstructions)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return userID, false, nil
+}
+
+func (b *TelegramBot) link(chatID int64, code string) error {
+	if code == "" {
+		return b.Client.SendHTMLMessage(chatID, "Usage: /link &lt;code&gt;")
+	}
+	_, err := b.Links.LinkChat(chatID, code)
+	if errors.Is(err, ErrInvalidLinkCode) {
+		return b.Client.SendHTMLMessage(chatID, "That code is invalid or has expired. Create a new one in bookmarker and try again.")
+	}
+	if err != nil {
+		return err
+	}
+	return b.Client.SendHTMLMessage(chatID, "This chat is now linked to your bookmarker account. Send a link to save it, or /help for commands.")
+}
+
+// HandleCommand runs a bot command for a linked chat and sends the reply; userID is the user the chat acts for
+func (b *TelegramBot) HandleCommand(chatID int64, userID int, text string) error {
+	command, args := parseTelegramCommand(text)
+	var reply string
+	var err error
+	switch command {
+	case "/start", "/help":
+		reply = telegramHelp
+	case "/search":
+		reply, err = b.search(userID, args)
+	case "/recent":
+		reply, err = b.recent(userID)
+	case "/unread":
+		reply, err = b.unread(userID)
+	case "/tags":
+		reply, err = b.tags(userID)
+	case "/tag":
+		reply, err = b.tag(userID, args)
+	case "/delete":
+		reply, err = b.delete(userID, args)
+	case "/unlink":
+		reply, err = b.unlink(chatID, userID)
+	default:
+		reply = "Unknown command " + html.EscapeString(command) + ". Send /help for the list of commands."
+	}
+	if err != nil {
+		return err
+	}
+	return b.Client.SendHTMLMessage(chatID, reply)
+}
+
+func (b *TelegramBot) search(userID int, query string) (string, error) {
+	if query == "" {
+		return "Usage: /search &lt;query&gt;", nil
+	}
+	bookmarks, err := b.Bookmarks.SearchBookmarks(query, ViewerFilter(userID), 1, telegramResultLimit)
+	if err != nil {
+		return "", err
+	}
+	return formatTelegramBookmarks("Results for “"+html.EscapeString(query)+"”", bookmarks, "Nothing found."), nil
+}
+
+func (b *TelegramBot) recent(userID int) (string, error) {
+	bookmarks, err := b.Bookmarks.ListBookmarksWithTags(ViewerFilter(userID), 1, telegramResultLimit)
+	if err != nil {
+		return "", err
+	}
+	return formatTelegramBookmarks("Recent bookmarks", bookmarks, "No bookmarks yet."), nil
+}
+
+func (b *TelegramBot) unread(userID int) (string, error) {
+	filter := ViewerFilter(userID)
+	filter.ReadStates = []string{models.ReadStateUnread}
+	bookmarks, err := b.Bookmarks.ListBookmarksWithTags(filter, 1, telegramResultLimit)
+	if err != nil {
+		return "", err
+	}
+	return formatTelegramBookmarks("Unread", bookmarks, "Your reading queue is empty."), nil
+}
+
+func (b *TelegramBot) tags(userID int) (string, error) {
+	counts, err := b.Bookmarks.ListTagCounts(ViewerFilter(userID), telegramResultLimit*2)
+	if err != nil {
+		return "", err
+	}
+	if len(counts) == 0 {
+		return "No tags yet.", nil
+	}
+	lines := []string{"<b>Tags</b>"}
+	for _, count := range counts {
+		lines = append(lines, fmt.Sprintf("%s (%d)", html.EscapeString(count.Name), count.Count))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (b *TelegramBot) tag(userID int, args string) (string, error) {
+	fields := strings.Fields(args)
+	id, err := strconv.Atoi(strings.TrimPrefix(firstField(fields), "#"))
+	if err != nil || len(fields) < 2 {
+		return "Usage: /tag &lt;id&gt; &lt;tags&gt;", nil
+	}
+	if err := b.Bookmarks.EnsureCanEdit(userID, id); err != nil {
+		return fmt.Sprintf("Bookmark #%d not found.", id), nil
+	}
+	bookmark, err := b.Bookmarks.GetBookmarkWithTags(id)
+	if err != nil {
+		return "", err
+	}
+	tags := append(sortedTagNames(bookmark.Tags), fields[1:]...)
+	bookmark, err = b.Bookmarks.UpdateBookmarkWithTags(userID, id, map[string]interface{}{}, tags)
+	if err != nil {
+		return "", err
+	}
+	return "Tagged " + formatTelegramBookmark(bookmark), nil
+}
+
+func (b *TelegramBot) delete(userID int, args string) (string, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(args), "#"))
+	if err != nil {
+		return "Usage: /delete &lt;id&gt;", nil
+	}
+	if err := b.Bookmarks.EnsureCanEdit(userID, id); err != nil {
+		return fmt.Sprintf("Bookmark #%d not found.", id), nil
+	}
+	if err := b.Bookmarks.DeleteBookmark(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Moved bookmark #%d to the trash.", id), nil
+}
+
+func (b *TelegramBot) unlink(chatID int64, userID int) (string, error) {
+	if err := b.Links.UnlinkChat(userID, chatID); err != nil {
+		return "", err
+	}
+	return "This chat is no longer linked. Send /link &lt;code&gt; to link it again.", nil
+}
+
+// parseTelegramCommand splits "/cmd@BotName args" into the lowercased command and the rest of the text
+func parseTelegramCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	command, args, _ := strings.Cut(text, " ")
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
+	}
+	return strings.ToLower(command), strings.TrimSpace(args)
+}
+
+// formatTelegramBookmarks renders a titled list of bookmarks, or empty when there are none
+func formatTelegramBookmarks(title string, bookmarks []models.Bookmark, empty string) string {
+	if len(bookmarks) == 0 {
+		return empty
+	}
+	lines := []string{"<b>" + title + "</b>"}
+	for _, bookmark := range bookmarks {
+		lines = append(lines, formatTelegramBookmark(bookmark))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// formatTelegramBookmark renders one bookmark as its ID, a link and its tags
+func formatTelegramBookmark(bookmark models.Bookmark) string {
+	title := strings.TrimSpace(bookmark.Title)
+	if title == "" {
+		title = bookmark.URL
+	}
+	if utf8.RuneCountInString(title) > telegramTitleLength {
+		title = string([]rune(title)[:telegramTitleLength-1]) + "…"
+	}
+	line := fmt.Sprintf(`#%d <a href="%s">%s</a>`, bookmark.ID, html.EscapeString(bookmark.URL), html.EscapeString(title))
+	if len(bookmark.Tags) > 0 {
+		line += "\n" + html.EscapeString(strings.Join(sortedTagNames(bookmark.Tags), ", "))
+	}
+	return line
+}
+
+func firstField(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Callback actions of the buttons under saved bookmarks; callback data is "<action>:<bookmark id>"
+const (
+	telegramActionTags   = "tags"
+	telegramActionLater  = "later"
+	telegramActionRead   = "read"
+	telegramActionDelete = "del"
+	telegramActionUndo   = "undo"
+)
+
+// telegramTagPrompt starts the message asking for tags; the reply to it is matched by telegramTagPromptPattern
+const telegramTagPrompt = "Tags for #%d:"
+
+var telegramTagPromptPattern = regexp.MustCompile(`^Tags for #(\d+):`)
+
+// ConfirmSaved tells the chat a bookmark was saved, with buttons to tag, queue, delete or open it
+func (b *TelegramBot) ConfirmSaved(chatID int64, bookmark models.Bookmark) error {
+	return b.Client.SendHTMLMessageWithMarkup(chatID, "Saved "+formatTelegramBookmark(bookmark), telegramBookmarkKeyboard(bookmark))
+}
+
+// HandleCallback carries out the action of a pressed button and updates the message it belongs to
+func (b *TelegramBot) HandleCallback(query models.TelegramCallbackQuery) error {
+	if query.Message == nil {
+		return b.Client.AnswerCallbackQuery(query.ID, "This message is too old")
+	}
+	chatID, messageID := query.Message.Chat.ID, query.Message.MessageID
+	userID, err := b.Links.ChatUserID(chatID)
+	if err != nil {
+		return b.Client.AnswerCallbackQuery(query.ID, "This chat is not linked; send /link <code>")
+	}
+	action, idText, _ := strings.Cut(query.Data, ":")
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		return b.Client.AnswerCallbackQuery(query.ID, "Unknown action")
+	}
+
+	if action == telegramActionUndo {
+		bookmark, err := b.Bookmarks.RestoreBookmark(userID, id)
+		if errors.Is(err, ErrBookmarkNotFound) {
+			return b.Client.AnswerCallbackQuery(query.ID, "Bookmark not found")
+		}
+		if err != nil {
+			return err
+		}
+		return b.showBookmark(query, bookmark, "Restored")
+	}
+
+	if err := b.Bookmarks.EnsureCanEdit(userID, id); err != nil {
+		return b.Client.AnswerCallbackQuery(query.ID, "Bookmark not found")
+	}
+	switch action {
+	case telegramActionTags:
+		prompt := models.TelegramForceReply{ForceReply: true, InputFieldPlaceholder: "tag1 tag2"}
+		if err := b.Client.SendHTMLMessageWithMarkup(chatID, fmt.Sprintf(telegramTagPrompt, id)+" reply with the tags to add", prompt); err != nil {
+			return err
+		}
+		return b.Client.AnswerCallbackQuery(query.ID, "")
+	case telegramActionLater, telegramActionRead:
+		state := models.ReadStateUnread
+		if action == telegramActionRead {
+			state = models.ReadStateRead
+		}
+		if _, err := b.Bookmarks.SetReadState(id, state); err != nil {
+			return err
+		}
+		bookmark, err := b.Bookmarks.GetBookmarkWithTags(id)
+		if err != nil {
+			return err
+		}
+		return b.showBookmark(query, bookmark, "Marked "+state)
+	case telegramActionDelete:
+		if err := b.Bookmarks.DeleteBookmark(id); err != nil {
+			return err
+		}
+		undo := &models.TelegramInlineKeyboardMarkup{InlineKeyboard: [][]models.TelegramInlineKeyboardButton{{
+			{Text: "↩ Undo", CallbackData: fmt.Sprintf("%s:%d", telegramActionUndo, id)},
+		}}}
+		if err := b.Client.EditMessageText(chatID, messageID, fmt.Sprintf("Moved bookmark #%d to the trash.", id), undo); err != nil {
+			return err
+		}
+		return b.Client.AnswerCallbackQuery(query.ID, "Deleted")
+	}
+	return b.Client.AnswerCallbackQuery(query.ID, "Unknown action")
+}
+
+// HandleTagReply adds the tags from a reply to a tag prompt. It reports false for other messages.
+func (b *TelegramBot) HandleTagReply(chatID int64, userID int, message *models.TelegramMessage) (bool, error) {
+	prompt := message.ReplyToMessage
+	if prompt == nil || prompt.From == nil || !prompt.From.IsBot {
+		return false, nil
+	}
+	match := telegramTagPromptPattern.FindStringSubmatch(prompt.Text)
+	if match == nil {
+		return false, nil
+	}
+	id, _ := strconv.Atoi(match[1])
+	save := ParseTelegramMessage(message)
+	// Tags may be typed with or without the leading #
+	tags := append(save.Tags, strings.Fields(save.Note)...)
+	reply, err := b.tag(userID, strconv.Itoa(id)+" "+strings.Join(tags, " "))
+	if err != nil {
+		return true, err
+	}
+	return true, b.Client.SendHTMLMessage(chatID, reply)
+}
+
+// showBookmark redraws a confirmation message after an action and answers the button press
+func (b *TelegramBot) showBookmark(query models.TelegramCallbackQuery, bookmark models.Bookmark, notice string) error {
+	err := b.Client.EditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		"Saved "+formatTelegramBookmark(bookmark), telegramBookmarkKeyboard(bookmark))
+	if err != nil {
+		return err
+	}
+	return b.Client.AnswerCallbackQuery(query.ID, notice)
+}
+
+// telegramBookmarkKeyboard builds the buttons shown under a saved bookmark
+func telegramBookmarkKeyboard(bookmark models.Bookmark) *models.TelegramInlineKeyboardMarkup {
+	button := func(text, action string) models.TelegramInlineKeyboardButton {
+		return models.TelegramInlineKeyboardButton{Text: text, CallbackData: fmt.Sprintf("%s:%d", action, bookmark.ID)}
+	}
+	readButton := button("📖 Read later", telegramActionLater)
+	if bookmark.ReadState != nil && *bookmark.ReadState == models.ReadStateUnread {
+		readButton = button("✓ Mark read", telegramActionRead)
+	}
+	return &models.TelegramInlineKeyboardMarkup{InlineKeyboard: [][]models.TelegramInlineKeyboardButton{
+		{button("🏷 Add tags", telegramActionTags), readButton},
+		{button("🗑 Delete", telegramActionDelete), {Text: "Open ↗", URL: bookmark.URL}},
+	}}
+}
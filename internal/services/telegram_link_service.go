@@ -0,0 +1,42 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"errors"
+)
+
+// ErrInvalidLinkCode is returned for an unknown, used or expired link code.
+var ErrInvalidLinkCode = errors.New("invalid or expired link code")
+
+// TelegramLinkService links Telegram chats to users through one-time codes from LinkCodeService
+type TelegramLinkService struct {
+	TelegramRepo *repositories.TelegramRepository
+}
+
+func NewTelegramLinkService(telegramRepo *repositories.TelegramRepository) *TelegramLinkService {
+	return &TelegramLinkService{TelegramRepo: telegramRepo}
+}
+
+// LinkChat links the chat to the user who created the code
+func (s *TelegramLinkService) LinkChat(chatID int64, code string) (models.TelegramChat, error) {
+	chat, err := s.TelegramRepo.LinkChat(repositories.HashToken(normalizeLinkCode(code)), chatID)
+	if errors.Is(err, repositories.ErrLinkCodeNotFound) {
+		return chat, ErrInvalidLinkCode
+	}
+	return chat, err
+}
+
+// ChatUserID returns the user a chat acts for, or repositories.ErrChatNotLinked
+func (s *TelegramLinkService) ChatUserID(chatID int64) (int, error) {
+	userID, err := s.TelegramRepo.GetChatUserID(chatID)
+	return int(userID), err
+}
+
+func (s *TelegramLinkService) ListChats(userID int) ([]models.TelegramChat, error) {
+	return s.TelegramRepo.ListChats(userID)
+}
+
+func (s *TelegramLinkService) UnlinkChat(userID int, chatID int64) error {
+	return s.TelegramRepo.UnlinkChat(userID, chatID)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// ParseTelegramMessage extracts links, hashtags and the note from a message's text or caption.
+// Telegram marks links and hashtags with entities; messages without entities are split on whitespace.
+// A forward from a public channel without links of its own saves the link to the original post.
+func ParseTelegramMessage(message *models.TelegramMessage) LinkSave {
+	text, entities := message.Text, message.Entities
+	if text == "" {
+		text, entities = message.Caption, message.CaptionEntities
+	}
+
+	var save LinkSave
+	if len(entities) > 0 {
+		save = parseTelegramEntities(text, entities)
+	} else {
+		save = ParseLinkText(text)
+	}
+	if len(save.URLs) == 0 {
+		if postURL := forwardedPostURL(message); postURL != "" {
+			save.URLs = []string{postURL}
+		}
+	}
+	return save
+}
+
+// parseTelegramEntities uses url, text_link and hashtag entities. Offsets count UTF-16 code units,
+// so the text is sliced in that encoding.
+func parseTelegramEntities(text string, entities []models.TelegramMessageEntity) LinkSave {
+	var save LinkSave
+	units := utf16.Encode([]rune(text))
+	// removed marks the code units of links and hashtags, which are left out of the note
+	removed := make([]bool, len(units))
+	seen := map[string]bool{}
+	for _, entity := range entities {
+		start, end := entity.Offset, entity.Offset+entity.Length
+		if start < 0 || end > len(units) || start >= end {
+			continue
+		}
+		value := string(utf16.Decode(units[start:end]))
+		switch entity.Type {
+		case "url":
+			if link := normalizeTelegramURL(value); link != "" {
+				save.URLs = appendUnique(save.URLs, seen, link)
+				markRemoved(removed, start, end)
+			}
+		case "text_link":
+			// The link text stays in the note; only the hidden URL is saved
+			if link := normalizeTelegramURL(entity.URL); link != "" {
+				save.URLs = appendUnique(save.URLs, seen, link)
+			}
+		case "hashtag":
+			save.Tags = append(save.Tags, strings.TrimPrefix(value, "#"))
+			markRemoved(removed, start, end)
+		}
+	}
+
+	kept := make([]uint16, 0, len(units))
+	for i, unit := range units {
+		if removed[i] {
+			// Keep words apart where a link or hashtag was cut out
+			if i == 0 || !removed[i-1] {
+				kept = append(kept, ' ')
+			}
+			continue
+		}
+		kept = append(kept, unit)
+	}
+	save.Note = cleanNote(string(utf16.Decode(kept)))
+	return save
+}
+
+// forwardedPostURL returns the t.me link of a post forwarded from a public channel
+func forwardedPostURL(message *models.TelegramMessage) string {
+	chat, messageID := message.ForwardFromChat, message.ForwardFromMessageID
+	if origin := message.ForwardOrigin; origin != nil && origin.Type == "channel" {
+		chat, messageID = origin.Chat, origin.MessageID
+	}
+	if chat == nil || chat.Username == "" || messageID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/%s/%d", chat.Username, messageID)
+}
+
+// normalizeTelegramURL adds the scheme Telegram leaves off links like "example.com" and drops anything not http(s)
+func normalizeTelegramURL(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	if !isValidURL(link) {
+		return ""
+	}
+	return link
+}
+
+func markRemoved(removed []bool, start, end int) {
+	for i := start; i < end; i++ {
+		removed[i] = true
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bookmarker/internal/clients"
+	"bookmarker/internal/repositories"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// telegramPollTimeout is how many seconds getUpdates waits for new updates
+const telegramPollTimeout = 30
+
+// telegramPollBackoff is how long the poller waits after a failed request before trying again
+const telegramPollBackoff = 5 * time.Second
+
+// maxTelegramUpdateAttempts is how often an update that fails to save is retried before it is skipped
+const maxTelegramUpdateAttempts = 5
+
+// TelegramPoller fetches updates with getUpdates instead of receiving them through the webhook.
+// The offset of the next update is kept in the database, so restarts neither lose nor repeat updates.
+type TelegramPoller struct {
+	Bot          *TelegramBot
+	TelegramRepo *repositories.TelegramRepository
+	Timeout      int
+
+	attempts map[int64]int
+}
+
+func NewTelegramPoller(bot *TelegramBot, telegramRepo *repositories.TelegramRepository) *TelegramPoller {
+	return &TelegramPoller{
+		Bot:          bot,
+		TelegramRepo: telegramRepo,
+		Timeout:      telegramPollTimeout,
+		attempts:     map[int64]int{},
+	}
+}
+
+// PollOnce fetches one batch of updates and handles them with the same code as the webhook,
+// saving the offset after each one. It returns how many updates were handled.
+func (p *TelegramPoller) PollOnce() (int, error) {
+	botID := p.Bot.Client.BotID()
+	offset, err := p.TelegramRepo.GetPollOffset(botID)
+	if err != nil {
+		return 0, err
+	}
+	updates, err := p.Bot.Client.GetUpdates(offset, p.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	for i, update := range updates {
+		if _, err := p.Bot.HandleUpdate(update); err != nil {
+			p.attempts[update.UpdateID]++
+			if p.attempts[update.UpdateID] < maxTelegramUpdateAttempts {
+				return i, err
+			}
+			log.Printf("[TelegramPoller] Skipping update %d after %d failed attempts: %v", update.UpdateID, maxTelegramUpdateAttempts, err)
+		}
+		delete(p.attempts, update.UpdateID)
+		if err := p.TelegramRepo.SavePollOffset(botID, update.UpdateID+1); err != nil {
+			return i, err
+		}
+	}
+	return len(updates), nil
+}
+
+// Run polls until ctx is cancelled. Failures are retried after a pause, except for an active webhook,
+// which has to be removed first.
+func (p *TelegramPoller) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		_, err := p.PollOnce()
+		if errors.Is(err, clients.ErrTelegramWebhookActive) {
+			return err
+		}
+		if err != nil {
+			log.Printf("[TelegramPoller] Polling failed: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(telegramPollBackoff):
+			}
+		}
+	}
+	return nil
+}
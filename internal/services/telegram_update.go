@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"log"
+	"time"
+)
+
+// TelegramUpdateResult reports what the bot did with an update
+type TelegramUpdateResult struct {
+	Status    string            `json:"status"`
+	Bookmarks []models.Bookmark `json:"bookmarks,omitempty"`
+}
+
+// HandleUpdate processes one update from the Bot API, whether it arrived through the webhook or long polling.
+// Errors talking to Telegram are logged; only failures to save are returned, so the update can be retried.
+func (b *TelegramBot) HandleUpdate(update models.TelegramUpdate) (TelegramUpdateResult, error) {
+	// Buttons pressed under a saved bookmark
+	if update.CallbackQuery != nil {
+		if err := b.HandleCallback(*update.CallbackQuery); err != nil {
+			log.Printf("[TelegramBot] Failed to handle callback %q: %v", update.CallbackQuery.Data, err)
+		}
+		return TelegramUpdateResult{Status: "callback handled"}, nil
+	}
+
+	message := update.EffectiveMessage()
+	if message == nil {
+		return TelegramUpdateResult{Status: "unsupported update"}, nil
+	}
+	chatID := message.Chat.ID
+
+	// Only linked chats may use the bot; /link and unlinked chats are answered here
+	userID, handled, err := b.AuthorizeChat(chatID, message.Text)
+	if err != nil {
+		return TelegramUpdateResult{}, err
+	}
+	if handled {
+		return TelegramUpdateResult{Status: "chat not linked"}, nil
+	}
+
+	// Replies to the bot's "Tags for #id" prompt add tags to that bookmark
+	if !update.IsEdit() {
+		handled, err := b.HandleTagReply(chatID, userID, message)
+		if err != nil {
+			log.Printf("[TelegramBot] Failed to add tags from reply: %v", err)
+		}
+		if handled {
+			return TelegramUpdateResult{Status: "tags added"}, nil
+		}
+	}
+
+	// Bot commands such as /search are answered in the chat; edits of commands are ignored
+	if IsTelegramCommand(message.Text) {
+		if !update.IsEdit() {
+			if err := b.HandleCommand(chatID, userID, message.Text); err != nil {
+				log.Printf("[TelegramBot] Failed to handle command %q: %v", message.Text, err)
+			}
+		}
+		return TelegramUpdateResult{Status: "command handled"}, nil
+	}
+
+	save := ParseTelegramMessage(message)
+	if len(save.URLs) == 0 {
+		return TelegramUpdateResult{Status: "no valid url detected"}, nil
+	}
+	log.Printf("[TelegramBot] URLs detected: %q (tags: %v)", save.URLs, save.Tags)
+
+	// One bookmark per link, skipping links the user already bookmarked. Telegram redelivers an update
+	// that failed, so the links saved before the failure must not be saved again; edits are skipped silently.
+	owner := int64(userID)
+	bookmarks := make([]models.Bookmark, 0, len(save.URLs))
+	for _, url := range save.URLs {
+		existing, err := b.Bookmarks.ListBookmarks(repositories.BookmarkFilter{OwnerID: userID, URL: url}, 1, 1)
+		if err != nil {
+			return TelegramUpdateResult{}, err
+		}
+		if len(existing) > 0 {
+			if !update.IsEdit() {
+				if err := b.ConfirmSaved(chatID, existing[0]); err != nil {
+					log.Printf("[TelegramBot] Failed to send Telegram message: %v", err)
+				}
+				bookmarks = append(bookmarks, existing[0])
+			}
+			continue
+		}
+		// Title, description and thumbnail are left empty and filled from the link preview
+		bookmark, err := b.Bookmarks.CreateBookmarkWithTags(BookmarkInput{
+			URL:       url,
+			Tags:      save.Tags,
+			Notes:     save.Note,
+			CreatedAt: time.Now(),
+			UserID:    &owner,
+			ReadState: defaultReadState("TELEGRAM_DEFAULT_READ_STATE"),
+		})
+		if err != nil {
+			return TelegramUpdateResult{}, err
+		}
+		if err := b.ConfirmSaved(chatID, bookmark); err != nil {
+			log.Printf("[TelegramBot] Failed to send Telegram message: %v", err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return TelegramUpdateResult{Status: "bookmarks saved", Bookmarks: bookmarks}, nil
+}
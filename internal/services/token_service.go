@@ -14,8 +14,8 @@ func NewTokenService(tokenRepo *repositories.TokenRepository) *TokenService {
 	return &TokenService{TokenRepo: tokenRepo}
 }
 
-func (s *TokenService) CreateToken(userID int, token string, expiresAt time.Time) error {
-	return s.TokenRepo.CreateToken(userID, token, expiresAt)
+func (s *TokenService) CreateToken(userID int, sessionID int, token string, expiresAt time.Time) error {
+	return s.TokenRepo.CreateToken(userID, sessionID, token, expiresAt)
 }
 
 func (s *TokenService) FindByToken(token string) (*models.Token, error) {
@@ -4,10 +4,23 @@ import (
 	"bookmarker/internal/models"
 	"bookmarker/internal/repositories"
 	"errors"
+	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// MinPasswordLength is the shortest password accepted for new and changed passwords
+const MinPasswordLength = 8
+
+// ErrPasswordTooShort is returned for passwords shorter than MinPasswordLength.
+var ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+
+// ErrCannotDeleteSelf is returned when an admin tries to delete or disable their own account.
+var ErrCannotDeleteSelf = errors.New("cannot delete or disable your own account")
+
+// ErrInvalidTransferTarget is returned when bookmarks would be transferred to the deleted user or an unknown user.
+var ErrInvalidTransferTarget = errors.New("bookmarks must be transferred to another existing user")
+
 type UserService struct {
 	UserRepo *repositories.UserRepository
 }
@@ -28,6 +41,9 @@ func (s *UserService) CreateUser(username, password string) (models.User, error)
 	if username == "" || password == "" {
 		return models.User{}, errors.New("username and password required")
 	}
+	if len(password) < MinPasswordLength {
+		return models.User{}, ErrPasswordTooShort
+	}
 	_, err := s.UserRepo.GetUserByUsername(username)
 	if err == nil {
 		return models.User{}, errors.New("username already exists")
@@ -38,3 +54,71 @@ func (s *UserService) CreateUser(username, password string) (models.User, error)
 	}
 	return s.UserRepo.CreateUser(username, string(hash))
 }
+
+func (s *UserService) ListUsers() ([]models.User, error) {
+	return s.UserRepo.ListUsers()
+}
+
+// SetPassword hashes and stores a new password for the user
+func (s *UserService) SetPassword(userID int64, password string) error {
+	if len(password) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.UserRepo.UpdatePasswordHash(userID, string(hash))
+}
+
+// ResetPassword sets a new password for a user and logs them out everywhere
+func (s *UserService) ResetPassword(username, password string) (models.User, error) {
+	user, err := s.UserRepo.GetUserByUsername(username)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := s.SetPassword(user.ID, password); err != nil {
+		return models.User{}, err
+	}
+	return user, s.UserRepo.RevokeSessions(user.ID)
+}
+
+// SetDisabled disables or re-enables a user; disabled users cannot log in and lose their sessions
+func (s *UserService) SetDisabled(userID int64, disabled bool) (models.User, error) {
+	if err := s.UserRepo.SetDisabled(userID, disabled); err != nil {
+		return models.User{}, err
+	}
+	return s.UserRepo.GetUserByID(userID)
+}
+
+func (s *UserService) SetAdmin(userID int64, isAdmin bool) (models.User, error) {
+	if err := s.UserRepo.SetAdmin(userID, isAdmin); err != nil {
+		return models.User{}, err
+	}
+	return s.UserRepo.GetUserByID(userID)
+}
+
+// DeleteUser removes a user. With transferTo set, their bookmarks and collections move to that user
+// instead of being deleted.
+func (s *UserService) DeleteUser(userID int64, transferTo *int64) error {
+	if transferTo != nil {
+		if *transferTo == userID {
+			return ErrInvalidTransferTarget
+		}
+		if _, err := s.UserRepo.GetUserByID(*transferTo); errors.Is(err, repositories.ErrUserNotFound) {
+			return ErrInvalidTransferTarget
+		} else if err != nil {
+			return err
+		}
+	}
+	return s.UserRepo.DeleteUser(userID, transferTo)
+}
+
+// ResetTwoFactor turns two-factor authentication off for a user who lost their authenticator and recovery codes
+func (s *UserService) ResetTwoFactor(username string) (models.User, error) {
+	user, err := s.UserRepo.GetUserByUsername(username)
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, s.UserRepo.DisableTOTP(user.ID)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webhookDispatchInterval is how long the dispatcher waits between looking for due deliveries
+const webhookDispatchInterval = 5 * time.Second
+
+// webhookDispatchBatch is how many deliveries the dispatcher claims at once
+const webhookDispatchBatch = 20
+
+// webhookRequestTimeout bounds a single delivery request; it is also the lease on claimed deliveries
+const webhookRequestTimeout = 15 * time.Second
+
+// webhookRetryBase is the wait before the first retry; it doubles with every further attempt
+const webhookRetryBase = 30 * time.Second
+
+// maxWebhookAttempts is how often a delivery is sent before it is marked failed, about an hour after the first attempt
+const maxWebhookAttempts = 8
+
+// maxWebhookErrorLength caps the error kept in the delivery log
+const maxWebhookErrorLength = 500
+
+// WebhookDispatcher sends the deliveries queued in the outbox. Each request is a POST of the JSON payload with
+//
+//	X-Bookmarker-Event:     the event, e.g. bookmark.created
+//	X-Bookmarker-Delivery:  the delivery ID, the same for every retry
+//	X-Bookmarker-Timestamp: Unix seconds when the request was sent
+//	X-Bookmarker-Signature: sha256=<hex HMAC-SHA256 of "timestamp.body" with the webhook's secret>
+//
+// which matches the signature inbound hooks expect. Any 2xx response counts as delivered; other responses,
+// redirects included, and network errors are retried with exponential backoff. Response bodies are never stored.
+type WebhookDispatcher struct {
+	WebhookRepo repositories.WebhookRepository
+	Client      *http.Client
+}
+
+func NewWebhookDispatcher(webhookRepo repositories.WebhookRepository) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		WebhookRepo: webhookRepo,
+		Client:      newWebhookClient(),
+	}
+}
+
+// DispatchOnce sends one batch of due deliveries and returns how many were claimed
+func (d *WebhookDispatcher) DispatchOnce() (int, error) {
+	// Claimed deliveries are postponed by a lease that outlasts the batch, so a crash only delays them
+	deliveries, err := d.WebhookRepo.ClaimDueDeliveries(webhookDispatchBatch, webhookRequestTimeout*(webhookDispatchBatch+1))
+	if err != nil {
+		return 0, err
+	}
+	for _, delivery := range deliveries {
+		d.attempt(&delivery)
+		if err := d.WebhookRepo.SaveDeliveryAttempt(delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends a delivery and records the outcome on it
+func (d *WebhookDispatcher) attempt(delivery *models.WebhookDelivery) {
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+
+	status, err := d.send(*delivery, now)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+		return
+	}
+
+	message := truncateWebhookError(err.Error())
+	delivery.LastError = &message
+	if delivery.Attempts >= maxWebhookAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		log.Printf("[WebhookDispatcher] Giving up on delivery %d after %d attempts: %s", delivery.ID, delivery.Attempts, message)
+		return
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = now.Add(webhookRetryDelay(delivery.Attempts))
+}
+
+// send posts the signed payload and returns the response status, if there was a response
+func (d *WebhookDispatcher) send(delivery models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Bookmarker-Webhooks")
+	req.Header.Set("X-Bookmarker-Event", delivery.Event)
+	req.Header.Set("X-Bookmarker-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Bookmarker-Timestamp", timestamp)
+	req.Header.Set("X-Bookmarker-Signature", "sha256="+hex.EncodeToString(HookSignature(delivery.Secret, timestamp, body)))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Run dispatches until ctx is cancelled, sending full batches back to back and pausing when the outbox is drained
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := d.DispatchOnce()
+		if err != nil {
+			log.Printf("[WebhookDispatcher] Dispatch failed: %v", err)
+		}
+		if err == nil && n == webhookDispatchBatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(webhookDispatchInterval):
+		}
+	}
+}
+
+// webhookRetryDelay returns the wait after the given number of failed attempts: 30s, 1m, 2m, 4m, ...
+func webhookRetryDelay(attempts int) time.Duration {
+	return webhookRetryBase << (attempts - 1)
+}
+
+// truncateWebhookError shortens an error for the delivery log, keeping it valid UTF-8 for the database
+func truncateWebhookError(message string) string {
+	if len(message) > maxWebhookErrorLength {
+		message = message[:maxWebhookErrorLength] + "…"
+	}
+	return strings.ToValidUTF8(message, "\uFFFD")
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bookmarker/internal/models"
+	"bookmarker/internal/repositories"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// webhookDeliveryLogLimit is how many deliveries the delivery log shows
+const webhookDeliveryLogLimit = 50
+
+// ErrInvalidWebhookURL is returned for webhook URLs that are not absolute http(s) URLs or point at
+// loopback, link-local or private addresses.
+var ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https URL of a public host")
+
+// ErrInvalidWebhookEvent is returned for events other than the models.WebhookEvent* constants.
+var ErrInvalidWebhookEvent = errors.New("events must be bookmark.created, bookmark.updated, bookmark.deleted or tag.renamed")
+
+// WebhookEvent is the JSON body sent to a webhook
+type WebhookEvent struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookPayload encodes an event for the outbox
+func webhookPayload(event string, data interface{}) ([]byte, error) {
+	return json.Marshal(WebhookEvent{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+}
+
+// queueBookmarkEvent queues a delivery of the bookmark for its owner's webhooks; unowned bookmarks have none
+func (s *bookmarkService) queueBookmarkEvent(event string, bookmark models.Bookmark) error {
+	if s.webhookRepo == nil || bookmark.UserID == nil {
+		return nil
+	}
+	payload, err := webhookPayload(event, map[string]interface{}{"bookmark": bookmark})
+	if err != nil {
+		return err
+	}
+	return s.webhookRepo.EnqueueEvent(event, *bookmark.UserID, payload)
+}
+
+// WebhookService manages a user's outgoing webhooks and shows their delivery log
+type WebhookService struct {
+	WebhookRepo repositories.WebhookRepository
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{WebhookRepo: webhookRepo}
+}
+
+// CreateWebhook subscribes url to events, or to every event when none are given.
+// The returned webhook carries the signing secret, which is not shown again.
+func (s *WebhookService) CreateWebhook(userID int, url string, events []string) (models.Webhook, error) {
+	url = strings.TrimSpace(url)
+	if !isValidWebhookURL(url) {
+		return models.Webhook{}, ErrInvalidWebhookURL
+	}
+	events, err := webhookEvents(events)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	secret, err := generateSecureToken(32)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return s.WebhookRepo.CreateWebhook(models.Webhook{
+		UserID: int64(userID),
+		URL:    url,
+		Secret: secret,
+		Events: events,
+		Active: true,
+	})
+}
+
+func (s *WebhookService) ListWebhooks(userID int) ([]models.Webhook, error) {
+	return s.WebhookRepo.ListWebhooks(userID)
+}
+
+// UpdateWebhook changes the URL, events or active flag of one of the user's webhooks; nil leaves a value as it is
+func (s *WebhookService) UpdateWebhook(userID int, id int, url *string, events []string, active *bool) (models.Webhook, error) {
+	webhook, err := s.WebhookRepo.GetWebhook(userID, id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if url != nil {
+		webhook.URL = strings.TrimSpace(*url)
+		if !isValidWebhookURL(webhook.URL) {
+			return models.Webhook{}, ErrInvalidWebhookURL
+		}
+	}
+	if events != nil {
+		if webhook.Events, err = webhookEvents(events); err != nil {
+			return models.Webhook{}, err
+		}
+	}
+	if active != nil {
+		webhook.Active = *active
+	}
+	return s.WebhookRepo.UpdateWebhook(webhook)
+}
+
+func (s *WebhookService) DeleteWebhook(userID int, id int) error {
+	return s.WebhookRepo.DeleteWebhook(userID, id)
+}
+
+// ListDeliveries returns the latest deliveries of one of the user's webhooks, newest first
+func (s *WebhookService) ListDeliveries(userID int, id int) ([]models.WebhookDelivery, error) {
+	if _, err := s.WebhookRepo.GetWebhook(userID, id); err != nil {
+		return nil, err
+	}
+	return s.WebhookRepo.ListDeliveries(id, webhookDeliveryLogLimit)
+}
+
+// RetryDelivery sends a delivery of one of the user's webhooks again, whatever its status
+func (s *WebhookService) RetryDelivery(userID int, id int, deliveryID int64) (models.WebhookDelivery, error) {
+	if _, err := s.WebhookRepo.GetWebhook(userID, id); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return s.WebhookRepo.RetryDelivery(id, deliveryID)
+}
+
+// webhookEvents validates and deduplicates events; an empty list subscribes to every event
+func webhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return append([]string(nil), models.WebhookEvents...), nil
+	}
+	seen := map[string]bool{}
+	var valid []string
+	for _, event := range events {
+		if !models.IsValidWebhookEvent(event) {
+			return nil, ErrInvalidWebhookEvent
+		}
+		valid = appendUnique(valid, seen, event)
+	}
+	return valid, nil
+}